@@ -0,0 +1,122 @@
+package fx
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// latencyBucketsPerDoubling is resolution of AcquireLatencyTracker, 4 buckets give relative error below 19%.
+	latencyBucketsPerDoubling = 4
+	// latencyBuckets cover latencies from latencyBucketBase to about 67s, longer ones fall into the last bucket
+	latencyBuckets    = 26 * latencyBucketsPerDoubling
+	latencyBucketBase = time.Microsecond
+)
+
+// AcquireLatencyTracker records durations of successful acquires in logarithmic histogram, which reports acquire
+// latency distribution unlike cumulative pgxpool.Stat().AcquireDuration(). It is safe for concurrent use.
+type AcquireLatencyTracker struct {
+	buckets [latencyBuckets]atomic.Int64
+	max     atomic.Int64
+}
+
+type acquireStartKey struct{}
+
+// NewAcquireLatencyTracker returns empty tracker. It records acquires of pools configured with
+// WithAcquireLatencyTracker.
+func NewAcquireLatencyTracker() *AcquireLatencyTracker {
+	return &AcquireLatencyTracker{}
+}
+
+// WithAcquireLatencyTracker records acquires of the pool in t, which may be shared by several pools.
+func WithAcquireLatencyTracker(t *AcquireLatencyTracker) PoolOption {
+	return WithQueryTracer(t)
+}
+
+// Percentile returns acquire latency below which p percent of recorded acquires are, p in range [0, 100]. Result is
+// upper bound of histogram bucket, so it overestimates actual latency by less than 19%. Zero is returned when nothing
+// is recorded.
+func (t *AcquireLatencyTracker) Percentile(p float64) time.Duration {
+	var counts [latencyBuckets]int64
+	var total int64
+	for i := range t.buckets {
+		counts[i] = t.buckets[i].Load()
+		total += counts[i]
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := max(int64(math.Ceil(min(max(p, 0), 100)/100*float64(total))), 1)
+	for i, n := range counts {
+		rank -= n
+		if rank > 0 {
+			continue
+		}
+		if i == latencyBuckets-1 {
+			return time.Duration(t.max.Load())
+		}
+		return min(latencyBucketBound(i), time.Duration(t.max.Load()))
+	}
+	return time.Duration(t.max.Load())
+}
+
+// Reset drops recorded acquires. Acquires finished concurrently with Reset may be kept.
+func (t *AcquireLatencyTracker) Reset() {
+	for i := range t.buckets {
+		t.buckets[i].Store(0)
+	}
+	t.max.Store(0)
+}
+
+func (t *AcquireLatencyTracker) record(d time.Duration) {
+	i := 0
+	if d > latencyBucketBase {
+		i = min(int(latencyBucketsPerDoubling*math.Log2(float64(d)/float64(latencyBucketBase))), latencyBuckets-1)
+	}
+	t.buckets[i].Add(1)
+
+	for {
+		cur := t.max.Load()
+		if int64(d) <= cur || t.max.CompareAndSwap(cur, int64(d)) {
+			return
+		}
+	}
+}
+
+// latencyBucketBound returns upper bound of bucket i.
+func latencyBucketBound(i int) time.Duration {
+	return time.Duration(float64(latencyBucketBase) * math.Exp2(float64(i+1)/latencyBucketsPerDoubling))
+}
+
+func (t *AcquireLatencyTracker) TraceAcquireStart(
+	ctx context.Context,
+	_ *pgxpool.Pool,
+	_ pgxpool.TraceAcquireStartData,
+) context.Context {
+	return context.WithValue(ctx, acquireStartKey{}, time.Now())
+}
+
+func (t *AcquireLatencyTracker) TraceAcquireEnd(ctx context.Context, _ *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	start, ok := ctx.Value(acquireStartKey{}).(time.Time)
+	if !ok || data.Err != nil {
+		return
+	}
+	t.record(time.Since(start))
+}
+
+// TraceQueryStart makes AcquireLatencyTracker pgx.QueryTracer accepted as ConnConfig.Tracer, queries are not traced.
+func (t *AcquireLatencyTracker) TraceQueryStart(
+	ctx context.Context,
+	_ *pgx.Conn,
+	_ pgx.TraceQueryStartData,
+) context.Context {
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *AcquireLatencyTracker) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
@@ -0,0 +1,91 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+var errAcquireTimeout = acquireTimeoutError{}
+
+// acquireTimeoutError is returned by Acquire when WithAcquireTimeout expires. It matches context.DeadlineExceeded, so
+// callers checking for deadline keep working.
+type acquireTimeoutError struct{}
+
+func (acquireTimeoutError) Error() string {
+	return "postgres: timed out waiting for connection"
+}
+
+func (acquireTimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+func (acquireTimeoutError) Timeout() bool {
+	return true
+}
+
+// WithAcquireTimeout bounds waiting for a free connection in Acquire and in query methods of the pool by d. Deadline
+// of caller context is kept when it is earlier. pgxpool has no setting for it, so timeout is set by pgxpool.AcquireTracer
+// and applies only to waiting for connection, not to the query made with it.
+func WithAcquireTimeout(d time.Duration) PoolOption {
+	return func(o *poolOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("acquire_timeout: must be positive, got %s", d)
+		}
+		return WithQueryTracer(&acquireTimeoutTracer{timeout: d})(o)
+	}
+}
+
+// IsAcquireTimeoutError reports whether err is returned because timeout set by WithAcquireTimeout expired.
+func IsAcquireTimeoutError(err error) bool {
+	return errors.Is(err, errAcquireTimeout)
+}
+
+// acquireTimeoutCtx reports errAcquireTimeout instead of context.DeadlineExceeded when its own deadline expires.
+type acquireTimeoutCtx struct {
+	context.Context
+	parent context.Context
+}
+
+type acquireCancelKey struct{}
+
+func (c *acquireTimeoutCtx) Err() error {
+	err := c.Context.Err()
+	if errors.Is(err, context.DeadlineExceeded) && c.parent.Err() == nil {
+		return errAcquireTimeout
+	}
+	return err
+}
+
+// acquireTimeoutTracer is pgx.QueryTracer only to be accepted as ConnConfig.Tracer, queries are not traced.
+type acquireTimeoutTracer struct {
+	timeout time.Duration
+}
+
+func (t *acquireTimeoutTracer) TraceAcquireStart(
+	ctx context.Context,
+	_ *pgxpool.Pool,
+	_ pgxpool.TraceAcquireStartData,
+) context.Context {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= t.timeout {
+		return ctx
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	return &acquireTimeoutCtx{Context: context.WithValue(timeoutCtx, acquireCancelKey{}, cancel), parent: ctx}
+}
+
+func (t *acquireTimeoutTracer) TraceAcquireEnd(ctx context.Context, _ *pgxpool.Pool, _ pgxpool.TraceAcquireEndData) {
+	if cancel, ok := ctx.Value(acquireCancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+func (t *acquireTimeoutTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return ctx
+}
+
+func (t *acquireTimeoutTracer) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
@@ -0,0 +1,123 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestIsAcquireTimeoutError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "acquire timeout", err: errAcquireTimeout, want: true},
+		{name: "wrapped acquire timeout", err: fmt.Errorf("query: %w", errAcquireTimeout), want: true},
+		{name: "caller deadline", err: context.DeadlineExceeded},
+		{name: "caller cancellation", err: context.Canceled},
+		{name: "other error", err: errors.New("failed")},
+		{name: "nil", err: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAcquireTimeoutError(tt.err); got != tt.want {
+				t.Errorf("IsAcquireTimeoutError(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	if !errors.Is(errAcquireTimeout, context.DeadlineExceeded) {
+		t.Errorf("acquire timeout does not match %v", context.DeadlineExceeded)
+	}
+}
+
+func TestWithAcquireTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		ctx         func() (context.Context, context.CancelFunc)
+		wantTimeout bool
+		wantErr     error
+	}{
+		{
+			name: "timeout of option",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			wantTimeout: true,
+			wantErr:     context.DeadlineExceeded,
+		},
+		{
+			name: "earlier caller deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "caller cancellation",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(10*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newStuckPool(t, WithAcquireTimeout(100*time.Millisecond))
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			start := time.Now()
+			_, err := pool.Acquire(ctx)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("acquire took %s", elapsed)
+			}
+
+			if got := IsAcquireTimeoutError(err); got != tt.wantTimeout {
+				t.Errorf("IsAcquireTimeoutError(%v) = %t, want %t", err, got, tt.wantTimeout)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithAcquireTimeoutValidation(t *testing.T) {
+	_, err := newPoolOptions("", "postgres://user@localhost/db", NopLogger(), []PoolOption{WithAcquireTimeout(0)})
+	if err == nil {
+		t.Error("zero acquire timeout is accepted")
+	}
+}
+
+// newStuckPool returns pool which connections never finish dialing, so Acquire waits until its context is done.
+func newStuckPool(t *testing.T, opts ...PoolOption) *pgxpool.Pool {
+	t.Helper()
+
+	opts = append([]PoolOption{WithDialFunc(func(ctx context.Context, _, _ string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})}, opts...)
+	o, err := newPoolOptions("", "postgres://user@localhost/db?pool_max_conns=1", NopLogger(), opts)
+	if err != nil {
+		t.Fatalf("newPoolOptions: %v", err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), o.config)
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return pool
+}
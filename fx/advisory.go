@@ -0,0 +1,151 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync"
+)
+
+// connAcquirer is implemented by pools able to hand out dedicated connection, e.g. *pgxpool.Pool.
+type connAcquirer interface {
+	Acquire(ctx context.Context) (*pgxpool.Conn, error)
+}
+
+// AcquireAdvisoryLock waits for advisory lock key held until release is called. When q can acquire dedicated
+// connection, e.g. q is *pgxpool.Pool, it takes session level lock on that connection. Release unlocks key and returns
+// the connection to the pool; if unlock fails, the connection is closed, which releases the lock as well. Otherwise,
+// q begins transaction holding transaction level lock, and release commits it.
+func AcquireAdvisoryLock(ctx context.Context, q Querier, key int64) (release func() error, err error) {
+	a, ok := q.(connAcquirer)
+	if !ok {
+		tx, err := beginAdvisoryTx(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		if err = AcquireAdvisoryXactLock(ctx, tx, key); err != nil {
+			_ = tx.Rollback(ctx)
+			return nil, err
+		}
+		return advisoryTxRelease(tx, key), nil
+	}
+
+	conn, err := a.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: acquire advisory lock connection: %w", err)
+	}
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("postgres: advisory lock %d: %w", key, err)
+	}
+
+	return advisoryRelease(conn, key), nil
+}
+
+// TryAdvisoryLock is non-blocking version of AcquireAdvisoryLock. When lock is held by another session, it returns
+// false and no-op release.
+func TryAdvisoryLock(ctx context.Context, q Querier, key int64) (acquired bool, release func() error, err error) {
+	a, ok := q.(connAcquirer)
+	if !ok {
+		tx, err := beginAdvisoryTx(ctx, q)
+		if err != nil {
+			return false, nil, err
+		}
+		if acquired, err = TryAdvisoryXactLock(ctx, tx, key); err != nil || !acquired {
+			_ = tx.Rollback(ctx)
+			if err != nil {
+				return false, nil, err
+			}
+			return false, func() error { return nil }, nil
+		}
+		return true, advisoryTxRelease(tx, key), nil
+	}
+
+	conn, err := a.Acquire(ctx)
+	if err != nil {
+		return false, nil, fmt.Errorf("postgres: acquire advisory lock connection: %w", err)
+	}
+	if err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Release()
+		return false, nil, fmt.Errorf("postgres: try advisory lock %d: %w", key, err)
+	}
+	if !acquired {
+		conn.Release()
+		return false, func() error { return nil }, nil
+	}
+
+	return true, advisoryRelease(conn, key), nil
+}
+
+// AcquireAdvisoryXactLock waits for transaction level advisory lock key. The lock is released at the end of tx.
+func AcquireAdvisoryXactLock(ctx context.Context, tx pgx.Tx, key int64) error {
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", key); err != nil {
+		return fmt.Errorf("postgres: advisory xact lock %d: %w", key, err)
+	}
+	return nil
+}
+
+// TryAdvisoryXactLock is non-blocking version of AcquireAdvisoryXactLock.
+func TryAdvisoryXactLock(ctx context.Context, tx pgx.Tx, key int64) (acquired bool, err error) {
+	if err = tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", key).Scan(&acquired); err != nil {
+		return false, fmt.Errorf("postgres: try advisory xact lock %d: %w", key, err)
+	}
+	return acquired, nil
+}
+
+func beginAdvisoryTx(ctx context.Context, q Querier) (pgx.Tx, error) {
+	tx, err := q.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("postgres: begin advisory lock transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// advisoryTxRelease commits tx holding transaction level lock key.
+func advisoryTxRelease(tx pgx.Tx, key int64) func() error {
+	var (
+		once sync.Once
+		err  error
+	)
+	return func() error {
+		once.Do(func() {
+			if err = tx.Commit(context.Background()); err != nil {
+				err = fmt.Errorf("postgres: advisory unlock %d: %w", key, err)
+			}
+		})
+		return err
+	}
+}
+
+func advisoryRelease(conn *pgxpool.Conn, key int64) func() error {
+	var (
+		once sync.Once
+		err  error
+	)
+
+	return func() error {
+		once.Do(func() {
+			err = advisoryUnlock(context.Background(), conn, key)
+		})
+		return err
+	}
+}
+
+// advisoryUnlock unlocks key and returns conn to the pool. If unlock fails, e.g. because ctx is done, conn is closed,
+// which releases the lock as well.
+func advisoryUnlock(ctx context.Context, conn *pgxpool.Conn, key int64) error {
+	var unlocked bool
+	err := conn.QueryRow(ctx, "SELECT pg_advisory_unlock($1)", key).Scan(&unlocked)
+	if err == nil && !unlocked {
+		err = errors.New("lock was not held")
+	}
+	if err != nil {
+		// closing the session releases all its advisory locks
+		_ = conn.Conn().Close(ctx)
+		err = fmt.Errorf("postgres: advisory unlock %d: %w", key, err)
+	}
+	conn.Release()
+	return err
+}
@@ -0,0 +1,35 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+// WithUsageAlert checks pool usage every pool HealthCheckPeriod and calls fn when share of acquired connections
+// reaches threshold in range (0, 1]. After a call fn is not called again until cooldown passes.
+func WithUsageAlert(threshold float64, cooldown time.Duration, fn func(stats PoolStats)) PoolOption {
+	return func(o *poolOptions) error {
+		if threshold <= 0 || threshold > 1 {
+			return fmt.Errorf("usage alert: threshold must be in range (0, 1], got %v", threshold)
+		}
+
+		o.background = append(o.background, func(ctx context.Context, pool *pgxpool.Pool) {
+			var lastAlert time.Time
+			every(ctx, pool.Config().HealthCheckPeriod, func() {
+				stats := SnapshotStats(pool)
+				if stats.MaxConns == 0 || float64(stats.AcquiredConns)/float64(stats.MaxConns) < threshold {
+					return
+				}
+				if !lastAlert.IsZero() && time.Since(lastAlert) < cooldown {
+					return
+				}
+
+				lastAlert = time.Now()
+				fn(stats)
+			})
+		})
+		return nil
+	}
+}
@@ -0,0 +1,37 @@
+// Package all is single import of every optional feature of github.com/grbisba/package/pgxpool/fx living in its own
+// module. Services that need only some of them should import the corresponding modules directly.
+package all
+
+import (
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/grbisba/package/pgxpool/fx/otel"
+	"github.com/grbisba/package/pgxpool/fx/pgvector"
+	pgxprometheus "github.com/grbisba/package/pgxpool/fx/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracing is otel.WithTracing.
+func WithTracing(tp trace.TracerProvider, opts ...otel.TracingOption) pgxfx.PoolOption {
+	return otel.WithTracing(tp, opts...)
+}
+
+// WithSampledTracing is otel.WithSampledTracing.
+func WithSampledTracing(tp trace.TracerProvider, sampleRate float64, opts ...otel.TracingOption) pgxfx.PoolOption {
+	return otel.WithSampledTracing(tp, sampleRate, opts...)
+}
+
+// WithMetrics is prometheus.WithMetrics.
+func WithMetrics(reg prometheus.Registerer) pgxfx.PoolOption {
+	return pgxprometheus.WithMetrics(reg)
+}
+
+// WithRetryMetrics is prometheus.WithRetryMetrics.
+func WithRetryMetrics(reg prometheus.Registerer, name string) (pgxfx.RetryOption, error) {
+	return pgxprometheus.WithRetryMetrics(reg, name)
+}
+
+// WithPgVector is pgvector.WithPgVector.
+func WithPgVector() pgxfx.PoolOption {
+	return pgvector.WithPgVector()
+}
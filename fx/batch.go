@@ -0,0 +1,108 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errEmptyBatch          = errors.New("postgres: batch is empty")
+	errBatchNoStatement    = errors.New("postgres: batch: Next was not called or returned false")
+	errBatchResultConsumed = errors.New("postgres: batch: result of statement is already consumed")
+)
+
+// BatchBuilder queues statements sent to server in single round trip.
+type BatchBuilder struct {
+	batch pgx.Batch
+}
+
+// NewBatchBuilder returns empty BatchBuilder.
+func NewBatchBuilder() *BatchBuilder {
+	return &BatchBuilder{}
+}
+
+// Add queues sql with args.
+func (b *BatchBuilder) Add(sql string, args ...any) *BatchBuilder {
+	b.batch.Queue(sql, args...)
+	return b
+}
+
+// Execute sends queued statements. Returned BatchResults must be closed.
+func (b *BatchBuilder) Execute(ctx context.Context, q Querier) (*BatchResults, error) {
+	if b.batch.Len() == 0 {
+		return nil, errEmptyBatch
+	}
+
+	return &BatchResults{results: q.SendBatch(ctx, &b.batch), left: b.batch.Len()}, nil
+}
+
+// BatchResults iterates over results of statements in order they were added. Next moves to the next statement, which
+// result is either scanned by Scan or checked by QueryError.
+type BatchResults struct {
+	results pgx.BatchResults
+	left    int
+	current bool
+	// consumed reports whether result of current statement is read
+	consumed bool
+	err      error
+	closed   bool
+}
+
+// Next moves to the next statement. It returns false when there are no more statements or results are closed.
+// Result of previous statement that was not read is discarded.
+func (r *BatchResults) Next() bool {
+	if r.current && !r.consumed {
+		r.exec()
+	}
+
+	r.current = !r.closed && r.left > 0
+	if r.current {
+		r.left--
+		r.consumed = false
+		r.err = nil
+	}
+	return r.current
+}
+
+// Scan reads the first row of current statement result into dest. It returns pgx.ErrNoRows when statement returned no
+// rows.
+func (r *BatchResults) Scan(dest ...any) error {
+	if !r.current {
+		return errBatchNoStatement
+	}
+	if r.consumed {
+		return errBatchResultConsumed
+	}
+
+	r.consumed = true
+	r.err = r.results.QueryRow().Scan(dest...)
+	return r.err
+}
+
+// QueryError returns error of current statement. It reads the result unless it was scanned already.
+func (r *BatchResults) QueryError() error {
+	if !r.current {
+		return errBatchNoStatement
+	}
+	if !r.consumed {
+		r.exec()
+	}
+	return r.err
+}
+
+// Close discards unread results and releases connection. It is safe to call Close several times.
+func (r *BatchResults) Close() error {
+	if r.closed {
+		return nil
+	}
+
+	r.closed = true
+	r.current = false
+	return r.results.Close()
+}
+
+func (r *BatchResults) exec() {
+	r.consumed = true
+	_, r.err = r.results.Exec()
+}
@@ -0,0 +1,134 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"slices"
+	"testing"
+)
+
+// fakeBatchResults answers queued statements in order with values or errors keyed by sql.
+type fakeBatchResults struct {
+	queries []*pgx.QueuedQuery
+	values  map[string]int64
+	errs    map[string]error
+	read    []string
+	closed  int
+}
+
+func (r *fakeBatchResults) next() string {
+	sql := r.queries[len(r.read)].SQL
+	r.read = append(r.read, sql)
+	return sql
+}
+
+func (r *fakeBatchResults) Exec() (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, r.errs[r.next()]
+}
+
+func (r *fakeBatchResults) Query() (pgx.Rows, error) {
+	panic("Query is not used by BatchResults")
+}
+
+func (r *fakeBatchResults) QueryRow() pgx.Row {
+	sql := r.next()
+	if err := r.errs[sql]; err != nil {
+		return errRow{err: err}
+	}
+	return intRow(r.values[sql])
+}
+
+func (r *fakeBatchResults) Close() error {
+	r.closed++
+	for len(r.read) < len(r.queries) {
+		r.next()
+	}
+	return nil
+}
+
+type intRow int64
+
+func (r intRow) Scan(dest ...any) error {
+	*dest[0].(*int64) = int64(r)
+	return nil
+}
+
+// batchQuerier is Querier answering SendBatch with results.
+type batchQuerier struct {
+	Querier
+
+	results *fakeBatchResults
+}
+
+func (q *batchQuerier) SendBatch(_ context.Context, b *pgx.Batch) pgx.BatchResults {
+	q.results.queries = b.QueuedQueries
+	return q.results
+}
+
+func TestBatchBuilder(t *testing.T) {
+	errInsert := errors.New("insert failed")
+	results := &fakeBatchResults{
+		values: map[string]int64{"SELECT 1": 1, "SELECT 3": 3},
+		errs:   map[string]error{"INSERT": errInsert},
+	}
+
+	br, err := NewBatchBuilder().
+		Add("SELECT 1").
+		Add("INSERT").
+		Add("UPDATE").
+		Add("SELECT 3").
+		Add("DELETE").
+		Execute(context.Background(), &batchQuerier{results: results})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	var v int64
+	if !br.Next() || br.Scan(&v) != nil || v != 1 {
+		t.Fatalf("first statement: value %d", v)
+	}
+	if err = br.Scan(&v); !errors.Is(err, errBatchResultConsumed) {
+		t.Errorf("second Scan err = %v, want %v", err, errBatchResultConsumed)
+	}
+
+	if !br.Next() {
+		t.Fatal("second statement is missing")
+	}
+	if err = br.QueryError(); !errors.Is(err, errInsert) {
+		t.Errorf("failed statement err = %v, want %v", err, errInsert)
+	}
+
+	// result of UPDATE is not read and must be discarded by Next
+	if !br.Next() || !br.Next() {
+		t.Fatal("fourth statement is missing")
+	}
+	if err = br.Scan(&v); err != nil || v != 3 {
+		t.Errorf("fourth statement: value %d, err %v", v, err)
+	}
+
+	if err = br.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if err = br.Close(); err != nil || results.closed != 1 {
+		t.Errorf("second Close err = %v, results closed %d times", err, results.closed)
+	}
+	if br.Next() {
+		t.Error("Next after Close returned true")
+	}
+	if err = br.QueryError(); !errors.Is(err, errBatchNoStatement) {
+		t.Errorf("QueryError after Close err = %v, want %v", err, errBatchNoStatement)
+	}
+
+	want := []string{"SELECT 1", "INSERT", "UPDATE", "SELECT 3", "DELETE"}
+	if !slices.Equal(results.read, want) {
+		t.Errorf("read results %v, want %v", results.read, want)
+	}
+}
+
+func TestBatchBuilderEmpty(t *testing.T) {
+	if _, err := NewBatchBuilder().Execute(context.Background(), nil); !errors.Is(err, errEmptyBatch) {
+		t.Errorf("err = %v, want %v", err, errEmptyBatch)
+	}
+}
@@ -0,0 +1,95 @@
+package fx
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker.Do when breaker does not allow calls.
+var ErrCircuitOpen = errors.New("postgres: circuit breaker is open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreakerConfig configures CircuitBreaker created by WithCircuitBreaker.
+type CircuitBreakerConfig struct {
+	// Threshold is the number of consecutive failures that opens the breaker.
+	Threshold uint
+	// ResetTimeout is the time after which opened breaker allows one probe call.
+	ResetTimeout time.Duration
+}
+
+// CircuitBreaker stops calling function after threshold consecutive failures. After resetTimeout it lets one probe call
+// through and closes on its success or opens again on its failure. It is safe for concurrent use.
+type CircuitBreaker struct {
+	threshold    uint
+	resetTimeout time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures uint
+	openedAt time.Time
+}
+
+// NewCircuitBreaker returns closed CircuitBreaker. Zero threshold is treated as 1.
+func NewCircuitBreaker(threshold uint, resetTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold:    max(threshold, 1),
+		resetTimeout: resetTimeout,
+	}
+}
+
+// Do calls f if breaker allows it and records the result. When breaker is open it returns ErrCircuitOpen without
+// calling f.
+func (cb *CircuitBreaker) Do(f func() error) error {
+	if !cb.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := f()
+	cb.record(err)
+
+	return err
+}
+
+func (cb *CircuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		if time.Since(cb.openedAt) < cb.resetTimeout {
+			return false
+		}
+		cb.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// probe is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+func (cb *CircuitBreaker) record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if err == nil {
+		cb.state = circuitClosed
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.state == circuitHalfOpen || cb.failures >= cb.threshold {
+		cb.state = circuitOpen
+		cb.openedAt = time.Now()
+	}
+}
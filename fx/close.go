@@ -0,0 +1,128 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync"
+	"time"
+)
+
+// WithCloseTimeout bounds waiting for acquired connections in OnStop by d. pgxpool.Pool.Close blocks until every
+// acquired connection is released; after d warning is logged, network connections of the acquired ones are closed, so
+// queries running on them fail, and OnStop returns without waiting for their release. Close keeps running in
+// background until they are released and its total duration is logged then. Connections that are never released
+// keep it running for the lifetime of the process.
+func WithCloseTimeout(d time.Duration) PoolOption {
+	return func(o *poolOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("close_timeout: must be positive, got %s", d)
+		}
+
+		t := &acquiredConns{conns: make(map[*pgx.Conn]struct{})}
+		o.closeTimeout = d
+		o.acquiredConns = t
+
+		o.afterRelease = append(o.afterRelease, func(conn *pgx.Conn) bool {
+			t.remove(conn)
+			return true
+		})
+		beforeClose := o.config.BeforeClose
+		o.config.BeforeClose = func(conn *pgx.Conn) {
+			if beforeClose != nil {
+				beforeClose(conn)
+			}
+			t.remove(conn)
+		}
+
+		return WithQueryTracer(t)(o)
+	}
+}
+
+// closePool closes pool, logging how long it took. With close timeout set, connections still acquired after it are
+// closed forcibly.
+func (o *poolOptions) closePool(pool *pgxpool.Pool) {
+	start := time.Now()
+	if o.closeTimeout <= 0 {
+		pool.Close()
+		o.log.Debug("closed postgres pool", "duration", time.Since(start))
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.Close()
+	}()
+
+	timer := time.NewTimer(o.closeTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		o.log.Debug("closed postgres pool", "duration", time.Since(start))
+	case <-timer.C:
+		o.log.Warn("timed out closing postgres pool, closing acquired connections",
+			"timeout", o.closeTimeout,
+			"acquired_conns", pool.Stat().AcquiredConns(),
+		)
+		o.acquiredConns.closeAll()
+
+		// Close keeps waiting until holders of the closed connections release them, which never happens if they
+		// are leaked, so it is only logged when it returns
+		go func() {
+			<-done
+			o.log.Debug("closed postgres pool after timeout", "duration", time.Since(start))
+		}()
+	}
+}
+
+// acquiredConns tracks connections acquired from the pool. It is pgx.QueryTracer only to be accepted as
+// ConnConfig.Tracer, queries are not traced.
+type acquiredConns struct {
+	mu    sync.Mutex
+	conns map[*pgx.Conn]struct{}
+}
+
+func (t *acquiredConns) TraceAcquireStart(
+	ctx context.Context,
+	_ *pgxpool.Pool,
+	_ pgxpool.TraceAcquireStartData,
+) context.Context {
+	return ctx
+}
+
+func (t *acquiredConns) TraceAcquireEnd(_ context.Context, _ *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	if data.Err != nil || data.Conn == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.conns[data.Conn] = struct{}{}
+}
+
+func (t *acquiredConns) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return ctx
+}
+
+func (t *acquiredConns) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+func (t *acquiredConns) remove(conn *pgx.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.conns, conn)
+}
+
+// closeAll closes network connections of acquired connections. Their holders get errors from running queries, and
+// the pool destroys them on release.
+func (t *acquiredConns) closeAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for conn := range t.conns {
+		_ = conn.PgConn().Conn().Close()
+	}
+	clear(t.conns)
+}
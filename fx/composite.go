@@ -0,0 +1,153 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"reflect"
+	"strings"
+)
+
+// compositeField is exported field of Go struct mapped to composite type attribute.
+type compositeField struct {
+	name string
+	typ  reflect.Type
+}
+
+// RegisterCompositeType returns AfterConnect hook registering composite type name mapped to struct T and its array
+// type. Exported fields of T are matched with type attributes in order by db tag or, if it is missing, by
+// case-insensitive field name. Fields tagged db:"-" are skipped. Hook fails with descriptive error if fields do not
+// match attributes.
+func RegisterCompositeType[T any](name string) func(ctx context.Context, conn *pgx.Conn) error {
+	return func(ctx context.Context, conn *pgx.Conn) error {
+		if _, ok := conn.TypeMap().TypeForName(name); ok {
+			return nil
+		}
+
+		fields, err := compositeFields(reflect.TypeFor[T]())
+		if err != nil {
+			return fmt.Errorf("postgres: composite type %s: %w", name, err)
+		}
+
+		oid, err := lookupTypeOID(ctx, conn, name)
+		if err != nil {
+			return err
+		}
+
+		codecFields, err := compositeCodecFields(ctx, conn, oid, fields)
+		if err != nil {
+			return fmt.Errorf("postgres: composite type %s: %w", name, err)
+		}
+
+		conn.TypeMap().RegisterType(&pgtype.Type{
+			Name:  name,
+			OID:   oid,
+			Codec: &pgtype.CompositeCodec{Fields: codecFields},
+		})
+		return RegisterArrayFor(name)(ctx, conn)
+	}
+}
+
+// WithCompositeTypes registers composite types with hooks returned by RegisterCompositeType for every new connection.
+func WithCompositeTypes(registrations ...AfterConnectHook) PoolOption {
+	return WithAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		for _, register := range registrations {
+			if err := register(ctx, conn); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func compositeFields(t reflect.Type) ([]compositeField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct", t)
+	}
+
+	var fields []compositeField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Tag.Get("db")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, compositeField{name: name, typ: f.Type})
+	}
+	return fields, nil
+}
+
+// compositeCodecFields loads attributes of composite type oid and checks they match fields.
+func compositeCodecFields(
+	ctx context.Context,
+	conn *pgx.Conn,
+	oid uint32,
+	fields []compositeField,
+) ([]pgtype.CompositeCodecField, error) {
+	rows, err := conn.Query(ctx, `SELECT a.attname, a.atttypid
+FROM pg_type t
+JOIN pg_attribute a ON a.attrelid = t.typrelid
+WHERE t.oid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`, oid)
+	if err != nil {
+		return nil, fmt.Errorf("load attributes: %w", err)
+	}
+
+	type attribute struct {
+		Name string
+		OID  uint32
+	}
+	attrs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[attribute])
+	if err != nil {
+		return nil, fmt.Errorf("load attributes: %w", err)
+	}
+
+	if len(attrs) == 0 {
+		return nil, fmt.Errorf("type is not composite")
+	}
+	if len(attrs) != len(fields) {
+		return nil, fmt.Errorf("type has %d attributes, struct has %d fields", len(attrs), len(fields))
+	}
+
+	codecFields := make([]pgtype.CompositeCodecField, len(attrs))
+	for i, attr := range attrs {
+		field := fields[i]
+		if !strings.EqualFold(attr.Name, field.name) {
+			return nil, fmt.Errorf("attribute %d is %q, struct field is %q", i+1, attr.Name, field.name)
+		}
+
+		typ, ok := conn.TypeMap().TypeForOID(attr.OID)
+		if !ok {
+			return nil, fmt.Errorf("attribute %q has unregistered type oid %d", attr.Name, attr.OID)
+		}
+		if err := checkEncodable(conn.TypeMap(), attr.OID, field.typ); err != nil {
+			return nil, fmt.Errorf("attribute %q of type %s does not match field of type %s: %w",
+				attr.Name, typ.Name, field.typ, err)
+		}
+
+		codecFields[i] = pgtype.CompositeCodecField{Name: attr.Name, Type: typ}
+	}
+	return codecFields, nil
+}
+
+// checkEncodable reports whether zero value of t can be encoded as type oid.
+func checkEncodable(m *pgtype.Map, oid uint32, t reflect.Type) error {
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+
+	value := reflect.New(t).Elem()
+	if t.Kind() == reflect.Pointer {
+		value = reflect.New(t.Elem())
+	}
+	_, err := m.Encode(oid, pgtype.BinaryFormatCode, value.Interface(), nil)
+	return err
+}
@@ -0,0 +1,238 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+	"golang.org/x/sync/semaphore"
+	"sync"
+)
+
+var _ Querier = (*ConcurrentPool)(nil)
+
+// ErrConcurrencyLimitReached is returned by ConcurrentPool.TryAcquire when the limit of concurrent callers is reached.
+var ErrConcurrencyLimitReached = errors.New("postgres: concurrency limit reached")
+
+// ConcurrentPool limits the number of callers working with the database at once regardless of pool size, so
+// goroutine burst does not overload the server even when MaxConns is high or connections are shared by services.
+// Slot taken by a query is held until its rows, row, batch results or transaction are done with.
+type ConcurrentPool struct {
+	pool *pgxpool.Pool
+	sem  *semaphore.Weighted
+}
+
+// ConcurrentConn is connection acquired by ConcurrentPool. It must be released with Release, which frees its slot.
+type ConcurrentConn struct {
+	*pgxpool.Conn
+	slot *slot
+}
+
+// WithConcurrencyLimit allows up to n callers to work with the pool at once. It is supported only by NewConcurrent,
+// other constructors fail when it is given.
+func WithConcurrencyLimit(n int) PoolOption {
+	return func(o *poolOptions) error {
+		if n <= 0 {
+			return fmt.Errorf("concurrency_limit: must be positive, got %d", n)
+		}
+		o.concurrencyLimit = n
+		return nil
+	}
+}
+
+// NewConcurrent creates pool like New does and wraps it into ConcurrentPool limited by WithConcurrencyLimit, which
+// must be present in opts.
+func NewConcurrent(lc fx.Lifecycle, dbUri string, log Logger, opts ...PoolOption) (*ConcurrentPool, error) {
+	allow := func(o *poolOptions) error {
+		o.concurrent = true
+		return nil
+	}
+	pool, o, err := providePool(lc, "", dbUri, log, append([]PoolOption{allow}, opts...))
+	if err != nil {
+		return nil, err
+	}
+	if o.concurrencyLimit == 0 {
+		return nil, errors.New("postgres: concurrent pool needs WithConcurrencyLimit")
+	}
+
+	return NewConcurrentPool(pool, o.concurrencyLimit)
+}
+
+// NewConcurrentPool returns ConcurrentPool allowing up to n concurrent callers of pool. n must be positive.
+func NewConcurrentPool(pool *pgxpool.Pool, n int) (*ConcurrentPool, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("postgres: concurrency limit must be positive, got %d", n)
+	}
+	return &ConcurrentPool{pool: pool, sem: semaphore.NewWeighted(int64(n))}, nil
+}
+
+// Pool returns the wrapped pool.
+func (p *ConcurrentPool) Pool() *pgxpool.Pool {
+	return p.pool
+}
+
+// Acquire waits for a free slot and then for a connection. Both waits are bounded by ctx.
+func (p *ConcurrentPool) Acquire(ctx context.Context) (*ConcurrentConn, error) {
+	if err := p.sem.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	return p.acquire(ctx)
+}
+
+// TryAcquire is Acquire returning ErrConcurrencyLimitReached instead of waiting for a free slot. It still may wait for
+// a connection when the pool is exhausted.
+func (p *ConcurrentPool) TryAcquire(ctx context.Context) (*ConcurrentConn, error) {
+	if !p.sem.TryAcquire(1) {
+		return nil, ErrConcurrencyLimitReached
+	}
+	return p.acquire(ctx)
+}
+
+// Exec runs sql holding a slot for its duration.
+func (p *ConcurrentPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if err := p.sem.Acquire(ctx, 1); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	defer p.sem.Release(1)
+
+	return p.pool.Exec(ctx, sql, args...)
+}
+
+// Query runs sql holding a slot until rows are closed or read to the end.
+func (p *ConcurrentPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if err := p.sem.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.pool.Query(ctx, sql, args...)
+	if err != nil {
+		p.sem.Release(1)
+		return nil, err
+	}
+	return &concurrentRows{Rows: rows, slot: p.newSlot()}, nil
+}
+
+// QueryRow runs sql holding a slot until the row is scanned.
+func (p *ConcurrentPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if err := p.sem.Acquire(ctx, 1); err != nil {
+		return errRow{err: err}
+	}
+	return &concurrentRow{row: p.pool.QueryRow(ctx, sql, args...), slot: p.newSlot()}
+}
+
+// SendBatch sends b holding a slot until batch results are closed.
+func (p *ConcurrentPool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	if err := p.sem.Acquire(ctx, 1); err != nil {
+		return errBatchResults{err: err}
+	}
+	return &concurrentBatchResults{BatchResults: p.pool.SendBatch(ctx, b), slot: p.newSlot()}
+}
+
+// BeginTx starts transaction holding a slot until it is committed or rolled back.
+func (p *ConcurrentPool) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	if err := p.sem.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	tx, err := p.pool.BeginTx(ctx, txOptions)
+	if err != nil {
+		p.sem.Release(1)
+		return nil, err
+	}
+	return &concurrentTx{Tx: tx, slot: p.newSlot()}, nil
+}
+
+// acquire acquires connection for slot taken by the caller.
+func (p *ConcurrentPool) acquire(ctx context.Context) (*ConcurrentConn, error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		p.sem.Release(1)
+		return nil, err
+	}
+	return &ConcurrentConn{Conn: conn, slot: p.newSlot()}, nil
+}
+
+// newSlot returns slot taken by the caller.
+func (p *ConcurrentPool) newSlot() *slot {
+	return &slot{sem: p.sem}
+}
+
+// Release returns connection to the pool and frees its slot. Subsequent calls do nothing.
+func (c *ConcurrentConn) Release() {
+	c.slot.release(c.Conn.Release)
+}
+
+// slot is a place taken in semaphore of ConcurrentPool. It is freed once, however many times release is called.
+type slot struct {
+	once sync.Once
+	sem  *semaphore.Weighted
+}
+
+// release calls fn, if any, and frees the slot. It does nothing on subsequent calls.
+func (s *slot) release(fn func()) {
+	s.once.Do(func() {
+		if fn != nil {
+			fn()
+		}
+		s.sem.Release(1)
+	})
+}
+
+type concurrentRows struct {
+	pgx.Rows
+	slot *slot
+}
+
+// Next frees the slot once rows are read to the end, as pgx closes them then.
+func (r *concurrentRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.slot.release(nil)
+	return false
+}
+
+func (r *concurrentRows) Close() {
+	r.slot.release(r.Rows.Close)
+}
+
+type concurrentRow struct {
+	row  pgx.Row
+	slot *slot
+}
+
+func (r *concurrentRow) Scan(dest ...any) error {
+	defer r.slot.release(nil)
+	return r.row.Scan(dest...)
+}
+
+type concurrentBatchResults struct {
+	pgx.BatchResults
+	slot *slot
+}
+
+func (r *concurrentBatchResults) Close() error {
+	err := r.BatchResults.Close()
+	r.slot.release(nil)
+	return err
+}
+
+type concurrentTx struct {
+	pgx.Tx
+	slot *slot
+}
+
+func (tx *concurrentTx) Commit(ctx context.Context) error {
+	err := tx.Tx.Commit(ctx)
+	tx.slot.release(nil)
+	return err
+}
+
+func (tx *concurrentTx) Rollback(ctx context.Context) error {
+	err := tx.Tx.Rollback(ctx)
+	tx.slot.release(nil)
+	return err
+}
@@ -0,0 +1,120 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx/fxtest"
+	"testing"
+)
+
+func TestWithConcurrencyLimitRejectedByOtherConstructors(t *testing.T) {
+	const dsn = "postgres://user@localhost/db"
+	opt := WithConcurrencyLimit(2)
+
+	tests := []struct {
+		name string
+		new  func(lc *fxtest.Lifecycle) error
+	}{
+		{name: "New", new: func(lc *fxtest.Lifecycle) error {
+			_, err := New(lc, dsn, NopLogger(), opt)
+			return err
+		}},
+		{name: "NewReplicaPool", new: func(lc *fxtest.Lifecycle) error {
+			_, err := NewReplicaPool(lc, []string{dsn}, NopLogger(), opt)
+			return err
+		}},
+		{name: "NewReadOnlyPool", new: func(lc *fxtest.Lifecycle) error {
+			_, err := NewReadOnlyPool(lc, dsn, NopLogger(), opt)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.new(fxtest.NewLifecycle(t)); err == nil {
+				t.Error("err = nil, want WithConcurrencyLimit to be rejected")
+			}
+		})
+	}
+
+	p, err := NewConcurrent(fxtest.NewLifecycle(t), dsn, NopLogger(), opt)
+	if err != nil {
+		t.Fatalf("NewConcurrent: %v", err)
+	}
+	t.Cleanup(p.Pool().Close)
+}
+
+func TestConcurrentPoolSlots(t *testing.T) {
+	pool, err := pgxpool.New(context.Background(), "postgres://user@localhost/db")
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	p, err := NewConcurrentPool(pool, 1)
+	if err != nil {
+		t.Fatalf("NewConcurrentPool: %v", err)
+	}
+
+	// take the only slot the way query methods do and report whether it is still taken after done
+	take := func() *slot {
+		if err := p.sem.Acquire(context.Background(), 1); err != nil {
+			t.Fatalf("acquire slot: %v", err)
+		}
+		return p.newSlot()
+	}
+	free := func() bool {
+		if !p.sem.TryAcquire(1) {
+			return false
+		}
+		p.sem.Release(1)
+		return true
+	}
+
+	tests := []struct {
+		name string
+		done func(s *slot)
+	}{
+		{name: "rows read to the end", done: func(s *slot) {
+			rows := &concurrentRows{Rows: &fakeRows{ids: []int64{1}}, slot: s}
+			for rows.Next() {
+			}
+			rows.Close()
+		}},
+		{name: "rows closed early", done: func(s *slot) {
+			rows := &concurrentRows{Rows: &fakeRows{ids: []int64{1, 2}}, slot: s}
+			rows.Next()
+			rows.Close()
+			rows.Close()
+		}},
+		{name: "row scanned", done: func(s *slot) {
+			row := &concurrentRow{row: errRow{err: errors.New("failed")}, slot: s}
+			_ = row.Scan()
+		}},
+		{name: "batch results closed", done: func(s *slot) {
+			results := &concurrentBatchResults{BatchResults: errBatchResults{}, slot: s}
+			_ = results.Close()
+		}},
+		{name: "tx committed and rolled back", done: func(s *slot) {
+			tx := &concurrentTx{Tx: &fakeTx{}, slot: s}
+			_ = tx.Commit(context.Background())
+			_ = tx.Rollback(context.Background())
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := take()
+			if _, err := p.TryAcquire(context.Background()); !errors.Is(err, ErrConcurrencyLimitReached) {
+				t.Fatalf("TryAcquire err = %v, want %v", err, ErrConcurrencyLimitReached)
+			}
+
+			// semaphore panics when released more times than acquired
+			tt.done(s)
+			if !free() {
+				t.Error("slot is not freed")
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+	"slices"
+	"time"
+)
+
+// PoolConfig is typed configuration of pool. Zero values of all fields but DSN keep pgxpool defaults.
+type PoolConfig struct {
+	DSN               string
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	ConnectTimeout    time.Duration
+}
+
+// DefaultPoolConfig returns PoolConfig with sensible defaults and empty DSN.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxConns:          10,
+		MaxConnLifetime:   time.Hour,
+		MaxConnIdleTime:   30 * time.Minute,
+		HealthCheckPeriod: time.Minute,
+		ConnectTimeout:    5 * time.Second,
+	}
+}
+
+// NewFromConfig validates cfg and creates pool like New does, applying cfg values after DSN parsing.
+func NewFromConfig(lc fx.Lifecycle, cfg PoolConfig, log Logger, opts ...PoolOption) (*pgxpool.Pool, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return newPool(lc, "", cfg.DSN, log, append([]PoolOption{cfg.option()}, opts...))
+}
+
+// Validate checks cfg and returns all found problems joined together.
+func (c PoolConfig) Validate() error {
+	if err := errors.Join(c.problems()...); err != nil {
+		return fmt.Errorf("postgres: invalid pool config: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateWith checks cfg like Validate and returns errs, e.g. of parsing values read by config loader, joined with
+// the found problems, so problems of the values parsed successfully are reported as well. Unlike Validate, the error
+// is not prefixed, so loaders can tell where the config comes from.
+func (c PoolConfig) ValidateWith(errs ...error) error {
+	return errors.Join(slices.Concat(errs, c.problems())...)
+}
+
+func (c PoolConfig) problems() []error {
+	var errs []error
+
+	for _, err := range validateDSN(c.DSN) {
+		errs = append(errs, fmt.Errorf("dsn: %w", err))
+	}
+	if c.MaxConns < 0 {
+		errs = append(errs, fmt.Errorf("max_conns: must not be negative, got %d", c.MaxConns))
+	}
+	if c.MinConns < 0 {
+		errs = append(errs, fmt.Errorf("min_conns: must not be negative, got %d", c.MinConns))
+	}
+	if c.MaxConns > 0 && c.MinConns > c.MaxConns {
+		errs = append(errs, fmt.Errorf("min_conns: must not exceed max_conns %d, got %d", c.MaxConns, c.MinConns))
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"max_conn_lifetime", c.MaxConnLifetime},
+		{"max_conn_idle_time", c.MaxConnIdleTime},
+		{"health_check_period", c.HealthCheckPeriod},
+		{"connect_timeout", c.ConnectTimeout},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("%s: must not be negative, got %s", d.name, d.value))
+		}
+	}
+
+	return errs
+}
+
+// option returns PoolOption applying non-zero values of c.
+func (c PoolConfig) option() PoolOption {
+	return func(o *poolOptions) error {
+		if c.MaxConns > 0 {
+			o.config.MaxConns = c.MaxConns
+		}
+		if c.MinConns > 0 {
+			o.config.MinConns = c.MinConns
+		}
+		if c.MaxConnLifetime > 0 {
+			o.config.MaxConnLifetime = c.MaxConnLifetime
+		}
+		if c.MaxConnIdleTime > 0 {
+			o.config.MaxConnIdleTime = c.MaxConnIdleTime
+		}
+		if c.HealthCheckPeriod > 0 {
+			o.config.HealthCheckPeriod = c.HealthCheckPeriod
+		}
+		if c.ConnectTimeout > 0 {
+			o.config.ConnConfig.ConnectTimeout = c.ConnectTimeout
+		}
+		return nil
+	}
+}
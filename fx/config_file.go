@@ -0,0 +1,117 @@
+package fx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileConfig is PoolConfig as written in configuration files. Durations are strings in time.ParseDuration format.
+type fileConfig struct {
+	DSN               *string `json:"dsn" yaml:"dsn"`
+	MaxConns          *int32  `json:"max_conns" yaml:"max_conns"`
+	MinConns          *int32  `json:"min_conns" yaml:"min_conns"`
+	MaxConnLifetime   *string `json:"max_conn_lifetime" yaml:"max_conn_lifetime"`
+	MaxConnIdleTime   *string `json:"max_conn_idle_time" yaml:"max_conn_idle_time"`
+	HealthCheckPeriod *string `json:"health_check_period" yaml:"health_check_period"`
+	ConnectTimeout    *string `json:"connect_timeout" yaml:"connect_timeout"`
+}
+
+// PoolConfigFromYAML reads PoolConfig from YAML document with keys named like PoolConfigFromEnv variables in lower
+// case, e.g. max_conns. Durations are written in time.ParseDuration format, e.g. 5m. Missing keys keep
+// DefaultPoolConfig values.
+func PoolConfigFromYAML(data []byte) (PoolConfig, error) {
+	var fc fileConfig
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return PoolConfig{}, fmt.Errorf("postgres: read pool config from yaml: %w", err)
+	}
+
+	return fc.poolConfig("yaml")
+}
+
+// PoolConfigFromJSON is PoolConfigFromYAML reading JSON document.
+func PoolConfigFromJSON(data []byte) (PoolConfig, error) {
+	var fc fileConfig
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fc); err != nil {
+		return PoolConfig{}, fmt.Errorf("postgres: read pool config from json: %w", err)
+	}
+
+	return fc.poolConfig("json")
+}
+
+// PoolConfigFromFile reads PoolConfig from YAML or JSON file depending on its extension: .yaml, .yml or .json.
+func PoolConfigFromFile(path string) (PoolConfig, error) {
+	var parse func([]byte) (PoolConfig, error)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		parse = PoolConfigFromYAML
+	case ".json":
+		parse = PoolConfigFromJSON
+	default:
+		return PoolConfig{}, fmt.Errorf("postgres: read pool config from %s: unknown extension %q, "+
+			"expected .yaml, .yml or .json", path, ext)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PoolConfig{}, fmt.Errorf("postgres: read pool config: %w", err)
+	}
+
+	return parse(data)
+}
+
+func (fc fileConfig) poolConfig(format string) (PoolConfig, error) {
+	cfg := DefaultPoolConfig()
+
+	if fc.DSN != nil {
+		cfg.DSN = *fc.DSN
+	}
+	if fc.MaxConns != nil {
+		cfg.MaxConns = *fc.MaxConns
+	}
+	if fc.MinConns != nil {
+		cfg.MinConns = *fc.MinConns
+	}
+
+	var errs []error
+	for _, v := range []struct {
+		name  string
+		value *string
+		dst   *time.Duration
+	}{
+		{"max_conn_lifetime", fc.MaxConnLifetime, &cfg.MaxConnLifetime},
+		{"max_conn_idle_time", fc.MaxConnIdleTime, &cfg.MaxConnIdleTime},
+		{"health_check_period", fc.HealthCheckPeriod, &cfg.HealthCheckPeriod},
+		{"connect_timeout", fc.ConnectTimeout, &cfg.ConnectTimeout},
+	} {
+		if v.value == nil {
+			continue
+		}
+
+		d, err := time.ParseDuration(*v.value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid duration %q, expected value like 30s, 5m or 1h", v.name, *v.value))
+			continue
+		}
+		*v.dst = d
+	}
+
+	if err := cfg.ValidateWith(errs...); err != nil {
+		return PoolConfig{}, fmt.Errorf("postgres: read pool config from %s: %w", format, err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,69 @@
+package fx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPoolConfigValidateWith(t *testing.T) {
+	errParse := errors.New("max_conns: invalid integer")
+	valid := DefaultPoolConfig()
+	valid.DSN = "postgres://user@localhost/db"
+	invalid := valid
+	invalid.MinConns = -1
+
+	tests := []struct {
+		name     string
+		cfg      PoolConfig
+		errs     []error
+		wantErr  bool
+		contains []string
+	}{
+		{name: "valid", cfg: valid},
+		{name: "parse error", cfg: valid, errs: []error{errParse}, wantErr: true, contains: []string{"invalid integer"}},
+		{name: "validation problem", cfg: invalid, wantErr: true, contains: []string{"min_conns"}},
+		{
+			name:     "parse error and validation problem",
+			cfg:      invalid,
+			errs:     []error{errParse},
+			wantErr:  true,
+			contains: []string{"invalid integer", "min_conns"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.ValidateWith(tt.errs...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+			for _, want := range tt.errs {
+				if !errors.Is(err, want) {
+					t.Errorf("err = %v, want wrapped %v", err, want)
+				}
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("err = %v, want it to mention %s", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPoolConfigFromEnvReportsAllProblems(t *testing.T) {
+	// problem of DSN, which is read successfully, is reported along with the parse error
+	t.Setenv("TEST_DSN", "")
+	t.Setenv("TEST_MAX_CONNS", "many")
+
+	_, err := PoolConfigFromEnv("test")
+	if err == nil {
+		t.Fatal("err = nil, want error")
+	}
+	for _, want := range []string{"read pool config from env", "TEST_MAX_CONNS", "dsn: must not be empty"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want it to mention %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+// BulkInsert inserts rows into tableName using COPY protocol and returns number of copied rows. valuesFn returns values
+// of row in columns order. Table name may be schema qualified. When q does not implement CopyFrom, rows are copied in
+// transaction begun by q.
+func BulkInsert[T any](
+	ctx context.Context,
+	q Querier,
+	tableName string,
+	columns []string,
+	rows []T,
+	valuesFn func(T) []any,
+) (n int64, err error) {
+	if c, ok := q.(copier); ok {
+		return bulkInsert(ctx, c.CopyFrom, tableName, columns, rows, valuesFn)
+	}
+
+	err = Transact(ctx, q, func(tx pgx.Tx) error {
+		n, err = BulkInsertTx(ctx, tx, tableName, columns, rows, valuesFn)
+		return err
+	})
+	if err != nil {
+		// rows copied by rolled back transaction are discarded
+		return 0, err
+	}
+	return n, nil
+}
+
+// BulkInsertTx is BulkInsert running inside tx.
+func BulkInsertTx[T any](
+	ctx context.Context,
+	tx pgx.Tx,
+	tableName string,
+	columns []string,
+	rows []T,
+	valuesFn func(T) []any,
+) (int64, error) {
+	return bulkInsert(ctx, tx.CopyFrom, tableName, columns, rows, valuesFn)
+}
+
+// copier is implemented by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type copier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+type copyFromFunc func(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error)
+
+func bulkInsert[T any](
+	ctx context.Context,
+	copyFrom copyFromFunc,
+	tableName string,
+	columns []string,
+	rows []T,
+	valuesFn func(T) []any,
+) (int64, error) {
+	ident, err := parseTableName(tableName)
+	if err != nil {
+		return 0, err
+	}
+	if err = checkIdentifiers("column", columns); err != nil {
+		return 0, err
+	}
+
+	n, err := copyFrom(ctx, ident, columns, pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+		return valuesFn(rows[i]), nil
+	}))
+	if err != nil {
+		return n, fmt.Errorf("postgres: bulk insert into %s: %w", tableName, err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,137 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/sync/singleflight"
+	"sync"
+	"time"
+)
+
+const (
+	// credentialRetryDelay is the time after failed call of CredentialProvider before it is called again.
+	credentialRetryDelay = time.Second
+	// credentialProviderTimeout bounds single call of CredentialProvider.
+	credentialProviderTimeout = 30 * time.Second
+)
+
+// CredentialProvider returns current user and password of the database, e.g. dynamic secret leased from Vault.
+type CredentialProvider func(ctx context.Context) (user, password string, err error)
+
+type credentialCache struct {
+	provider CredentialProvider
+	ttl      time.Duration
+	log      Logger
+	group    singleflight.Group
+
+	mu       sync.Mutex
+	user     string
+	password string
+	fetched  time.Time
+	err      error
+	failed   time.Time
+}
+
+// WithCredentialProvider makes every new connection authenticate with credentials returned by provider. Result of
+// provider is cached for ttl shared by the whole pool, so provider is called at most once per ttl regardless of the
+// number of connections, and concurrent callers share a single call. When credentials change, connections authenticated
+// with the previous ones are destroyed on the next acquire and replaced with new connections.
+//
+// Acquire never waits for provider: expired credentials are refreshed in background. Failed provider is not called
+// again for a second; meanwhile new connections use the last known credentials, or fail if there are none.
+func WithCredentialProvider(provider CredentialProvider, ttl time.Duration) PoolOption {
+	return func(o *poolOptions) error {
+		if ttl <= 0 {
+			return fmt.Errorf("credential ttl: must be positive, got %s", ttl)
+		}
+
+		c := &credentialCache{provider: provider, ttl: ttl, log: o.log}
+
+		beforeConnect := o.config.BeforeConnect
+		o.config.BeforeConnect = func(ctx context.Context, cfg *pgx.ConnConfig) error {
+			if beforeConnect != nil {
+				if err := beforeConnect(ctx, cfg); err != nil {
+					return err
+				}
+			}
+
+			user, password, err := c.get(ctx)
+			if err != nil {
+				return err
+			}
+			cfg.User, cfg.Password = user, password
+			return nil
+		}
+
+		o.beforeAcquire = append(o.beforeAcquire, func(ctx context.Context, conn *pgx.Conn) bool {
+			user, password, ok, stale, _ := c.snapshot()
+			if stale {
+				c.group.DoChan("", c.refresh)
+			}
+			if !ok {
+				return true
+			}
+
+			cfg := conn.Config()
+			if cfg.User != user || cfg.Password != password {
+				o.log.Debug("destroying postgres connection with rotated credentials", "pid", conn.PgConn().PID())
+				return false
+			}
+			return true
+		})
+		return nil
+	}
+}
+
+// get returns cached credentials, waiting for refresh if they are expired.
+func (c *credentialCache) get(ctx context.Context) (string, string, error) {
+	user, password, ok, stale, err := c.snapshot()
+	if stale {
+		select {
+		case <-c.group.DoChan("", c.refresh):
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		}
+		user, password, ok, _, err = c.snapshot()
+	}
+
+	if !ok {
+		return "", "", err
+	}
+	return user, password, nil
+}
+
+// snapshot returns the last known credentials, ok is false if there are none. stale reports whether provider should
+// be called; it is false while retry delay after failure has not passed. err is the last error of provider.
+func (c *credentialCache) snapshot() (user, password string, ok, stale bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ok = !c.fetched.IsZero()
+	backoff := !c.failed.IsZero() && time.Since(c.failed) < credentialRetryDelay
+	stale = (!ok || time.Since(c.fetched) >= c.ttl) && !backoff
+	return c.user, c.password, ok, stale, c.err
+}
+
+// refresh calls provider and caches its result. It is called through singleflight, so calls never overlap.
+func (c *credentialCache) refresh() (any, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialProviderTimeout)
+	defer cancel()
+
+	user, password, err := c.provider(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		c.err = fmt.Errorf("postgres: credential provider: %w", err)
+		c.failed = time.Now()
+		c.log.Warn("failed to refresh postgres credentials", "error", err)
+		return nil, c.err
+	}
+
+	c.user, c.password, c.fetched = user, password, time.Now()
+	c.err, c.failed = nil, time.Time{}
+	return nil, nil
+}
@@ -0,0 +1,70 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"net"
+	"time"
+)
+
+// WithDialFunc sets fn as dial function of connections, e.g. to route them through pgbouncer sidecar. It is mutually
+// exclusive with WithUnixSocket and WithTCPKeepalive.
+func WithDialFunc(fn pgconn.DialFunc) PoolOption {
+	return func(o *poolOptions) error {
+		return o.setDialFunc("WithDialFunc", fn)
+	}
+}
+
+// WithUnixSocket dials every connection to unix socket at socketPath and sets host of the config to localhost, as
+// required by pgx. TLS is not used over the socket. It is mutually exclusive with WithDialFunc and WithTCPKeepalive.
+func WithUnixSocket(socketPath string) PoolOption {
+	return func(o *poolOptions) error {
+		var dialer net.Dialer
+		err := o.setDialFunc("WithUnixSocket", func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		})
+		if err != nil {
+			return err
+		}
+
+		cc := &o.config.ConnConfig.Config
+		cc.Host = "localhost"
+		cc.TLSConfig = nil
+		cc.Fallbacks = nil
+		o.unixSocket = socketPath
+		return nil
+	}
+}
+
+// WithTCPKeepalive dials connections with TCP keepalive probes sent every interval of idleness, so connections silently
+// dropped by NAT, e.g. in Kubernetes, are detected before the next query. It is mutually exclusive with WithDialFunc and
+// WithUnixSocket.
+func WithTCPKeepalive(interval time.Duration) PoolOption {
+	return func(o *poolOptions) error {
+		if interval <= 0 {
+			return fmt.Errorf("postgres: tcp keepalive interval must be positive, got %s", interval)
+		}
+
+		dialer := &net.Dialer{
+			KeepAlive: interval,
+			KeepAliveConfig: net.KeepAliveConfig{
+				Enable:   true,
+				Idle:     interval,
+				Interval: interval,
+			},
+		}
+		return o.setDialFunc("WithTCPKeepalive", dialer.DialContext)
+	}
+}
+
+// setDialFunc sets dial function of connections, rejecting options that set it already.
+func (o *poolOptions) setDialFunc(option string, fn pgconn.DialFunc) error {
+	if o.dialFuncOption != "" && o.dialFuncOption != option {
+		return fmt.Errorf("postgres: %s and %s are mutually exclusive", o.dialFuncOption, option)
+	}
+
+	o.dialFuncOption = option
+	o.config.ConnConfig.DialFunc = fn
+	return nil
+}
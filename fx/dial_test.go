@@ -0,0 +1,42 @@
+package fx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithTCPKeepaliveOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []PoolOption
+		wantErr string
+	}{
+		{
+			name:    "non-positive interval",
+			opts:    []PoolOption{WithTCPKeepalive(0)},
+			wantErr: "tcp keepalive interval must be positive",
+		},
+		{
+			name:    "exclusive with unix socket",
+			opts:    []PoolOption{WithUnixSocket("/tmp/.s.PGSQL.5432"), WithTCPKeepalive(time.Minute)},
+			wantErr: "WithUnixSocket and WithTCPKeepalive are mutually exclusive",
+		},
+		{
+			name: "repeated option",
+			opts: []PoolOption{WithTCPKeepalive(time.Minute), WithTCPKeepalive(time.Second)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newPoolOptions("", "postgres://user@localhost/db", NopLogger(), tt.opts)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("err = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+//go:build unix
+
+package fx
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithTCPKeepalive(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+		close(accepted)
+	}()
+
+	o, err := newPoolOptions("", "postgres://user@localhost/db", NopLogger(), []PoolOption{WithTCPKeepalive(time.Minute)})
+	if err != nil {
+		t.Fatalf("newPoolOptions: %v", err)
+	}
+
+	conn, err := o.config.ConnConfig.DialFunc(context.Background(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	select {
+	case server := <-accepted:
+		if server == nil {
+			t.Fatal("listener did not accept connection")
+		}
+		server.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not accept connection")
+	}
+
+	tcp, ok := conn.(*net.TCPConn)
+	if !ok {
+		t.Fatalf("dialed %T, want *net.TCPConn", conn)
+	}
+	raw, err := tcp.SyscallConn()
+	if err != nil {
+		t.Fatalf("syscall conn: %v", err)
+	}
+
+	var keepalive int
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		keepalive, sockErr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_KEEPALIVE)
+	})
+	if err != nil || sockErr != nil {
+		t.Fatalf("read SO_KEEPALIVE: %v, %v", err, sockErr)
+	}
+	if keepalive == 0 {
+		t.Error("SO_KEEPALIVE is not enabled on dialed connection")
+	}
+}
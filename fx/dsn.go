@@ -0,0 +1,281 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const maskedPassword = "***"
+
+var (
+	// password in key=value DSN, either quoted with backslash escapes or bare
+	kvPasswordRegexp = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|[^\s]+)`)
+	// password passed as query parameter of URI DSN
+	queryPasswordRegexp = regexp.MustCompile(`([?&]password=)([^&#]*)`)
+)
+
+// MaskDSN replaces password in URI or key=value dsn with ***, so dsn can be logged. Password is replaced as written, so
+// percent-encoded passwords are masked entirely.
+func MaskDSN(dsn string) string {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return kvPasswordRegexp.ReplaceAllString(dsn, "${1}"+maskedPassword)
+	}
+
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		userinfo := rest[:at]
+		// user name can not contain path or query delimiters, otherwise @ belongs to them
+		if user, _, hasPassword := strings.Cut(userinfo, ":"); hasPassword && !strings.ContainsAny(user, "/?#") {
+			rest = user + ":" + maskedPassword + rest[at:]
+		}
+	}
+
+	return scheme + "://" + queryPasswordRegexp.ReplaceAllString(rest, "${1}"+maskedPassword)
+}
+
+// UnmaskDSN returns dsn with password replaced by ***, so it can be logged. It is the same as MaskDSN, which is the
+// preferred name, as the returned dsn is masked rather than unmasked.
+func UnmaskDSN(dsn string) string {
+	return MaskDSN(dsn)
+}
+
+// kvSettingRegexp matches setting of key=value DSN, value is either quoted with backslash escapes or bare
+var kvSettingRegexp = regexp.MustCompile(`(\w+)\s*=\s*('(?:[^'\\]|\\.)*'|\S*)`)
+
+// ValidateDSN checks URI or key=value dsn for common mistakes before it is parsed by pgx: missing host or database,
+// invalid port and unknown sslmode. Missing values may be provided by PGHOST, PGPORT, PGDATABASE and PGSSLMODE
+// environment variables, like pgx does. All problems are returned joined together.
+func ValidateDSN(dsn string) error {
+	if err := errors.Join(validateDSN(dsn)...); err != nil {
+		return fmt.Errorf("postgres: invalid dsn: %w", err)
+	}
+	return nil
+}
+
+func validateDSN(dsn string) []error {
+	if strings.TrimSpace(dsn) == "" {
+		return []error{errors.New("must not be empty")}
+	}
+
+	settings, err := dsnSettings(dsn)
+	if err != nil {
+		return []error{err}
+	}
+	for key, env := range map[string]string{
+		"host":    "PGHOST",
+		"port":    "PGPORT",
+		"dbname":  "PGDATABASE",
+		"sslmode": "PGSSLMODE",
+	} {
+		if settings[key] == "" {
+			settings[key] = os.Getenv(env)
+		}
+	}
+
+	var errs []error
+	if settings["host"] == "" {
+		errs = append(errs, errors.New("host: must not be empty"))
+	}
+	if settings["port"] != "" {
+		for _, port := range strings.Split(settings["port"], ",") {
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				errs = append(errs, fmt.Errorf("port: must be number in range 1-65535, got %q", port))
+			}
+		}
+	}
+	if settings["dbname"] == "" {
+		errs = append(errs, errors.New("database: must not be empty"))
+	}
+	if mode := settings["sslmode"]; mode != "" && !slices.Contains(sslModes, mode) {
+		errs = append(errs, fmt.Errorf("sslmode: unknown mode %q", mode))
+	}
+	return errs
+}
+
+// dsnSettings returns host, port, dbname and sslmode settings of dsn. Ports of several hosts are joined with comma.
+func dsnSettings(dsn string) (map[string]string, error) {
+	settings := make(map[string]string)
+
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		for _, m := range kvSettingRegexp.FindAllStringSubmatch(dsn, -1) {
+			value := m[2]
+			if strings.HasPrefix(value, "'") {
+				value = strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(value[1 : len(value)-1])
+			}
+			settings[m[1]] = value
+		}
+		return settings, nil
+	}
+
+	if scheme != "postgres" && scheme != "postgresql" {
+		return nil, fmt.Errorf("scheme: must be postgres or postgresql, got %q", scheme)
+	}
+
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+	authority, database, _ := strings.Cut(rest, "/")
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		authority = authority[at+1:]
+	}
+
+	var hosts, ports []string
+	for _, hostPort := range strings.Split(authority, ",") {
+		host, port := hostPort, ""
+		if strings.HasPrefix(hostPort, "[") {
+			// IPv6 address
+			if end := strings.Index(hostPort, "]"); end >= 0 {
+				host, port = hostPort[:end+1], strings.TrimPrefix(hostPort[end+1:], ":")
+			}
+		} else if i := strings.LastIndex(hostPort, ":"); i >= 0 {
+			host, port = hostPort[:i], hostPort[i+1:]
+		}
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+		if port != "" {
+			ports = append(ports, port)
+		}
+	}
+	settings["host"] = strings.Join(hosts, ",")
+	settings["port"] = strings.Join(ports, ",")
+
+	if database, err := url.PathUnescape(database); err == nil {
+		settings["dbname"] = database
+	} else {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	// query parameters override values of URI parts, like in libpq
+	for key := range query {
+		settings[key] = query.Get(key)
+	}
+
+	return settings, nil
+}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// DSNBuilder assembles postgres:// URI from separate values, e.g. taken from secrets manager.
+type DSNBuilder struct {
+	host     string
+	port     int
+	user     string
+	password string
+	database string
+	sslMode  string
+	options  map[string]string
+}
+
+// NewDSNBuilder returns empty DSNBuilder.
+func NewDSNBuilder() *DSNBuilder {
+	return &DSNBuilder{}
+}
+
+// Host sets server host name or IP address. It is required.
+func (b *DSNBuilder) Host(host string) *DSNBuilder {
+	b.host = host
+	return b
+}
+
+// Port sets server port. Unset port means default one.
+func (b *DSNBuilder) Port(port int) *DSNBuilder {
+	b.port = port
+	return b
+}
+
+// User sets user name. It is required.
+func (b *DSNBuilder) User(user string) *DSNBuilder {
+	b.user = user
+	return b
+}
+
+// Password sets user password. It is percent-encoded by Build.
+func (b *DSNBuilder) Password(password string) *DSNBuilder {
+	b.password = password
+	return b
+}
+
+// Database sets database name. It is required.
+func (b *DSNBuilder) Database(database string) *DSNBuilder {
+	b.database = database
+	return b
+}
+
+// SSLMode sets sslmode parameter, e.g. "disable" or "verify-full".
+func (b *DSNBuilder) SSLMode(mode string) *DSNBuilder {
+	b.sslMode = mode
+	return b
+}
+
+// Options sets additional connection parameters, e.g. application_name. They are added to previously set ones.
+func (b *DSNBuilder) Options(options map[string]string) *DSNBuilder {
+	if b.options == nil {
+		b.options = make(map[string]string, len(options))
+	}
+	for k, v := range options {
+		b.options[k] = v
+	}
+	return b
+}
+
+// Build validates values and returns postgres:// URI. Use MaskDSN to log it.
+func (b *DSNBuilder) Build() (string, error) {
+	var errs []error
+	if b.host == "" {
+		errs = append(errs, errors.New("host: must not be empty"))
+	}
+	if b.user == "" {
+		errs = append(errs, errors.New("user: must not be empty"))
+	}
+	if b.database == "" {
+		errs = append(errs, errors.New("database: must not be empty"))
+	}
+	if b.port != 0 && (b.port < 1 || b.port > 65535) {
+		errs = append(errs, fmt.Errorf("port: must be in range 1-65535, got %d", b.port))
+	}
+	if b.sslMode != "" && !slices.Contains(sslModes, b.sslMode) {
+		errs = append(errs, fmt.Errorf("sslmode: unknown mode %q", b.sslMode))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return "", fmt.Errorf("postgres: invalid dsn: %w", err)
+	}
+
+	host := b.host
+	if b.port != 0 {
+		host = net.JoinHostPort(host, strconv.Itoa(b.port))
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	query := url.Values{}
+	for k, v := range b.options {
+		query.Set(k, v)
+	}
+	if b.sslMode != "" {
+		query.Set("sslmode", b.sslMode)
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.User(b.user),
+		Host:     host,
+		Path:     "/" + b.database,
+		RawQuery: query.Encode(),
+	}
+	if b.password != "" {
+		u.User = url.UserPassword(b.user, b.password)
+	}
+
+	return u.String(), nil
+}
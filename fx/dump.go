@@ -0,0 +1,82 @@
+package fx
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+)
+
+// DumpConfig returns JSON-serializable map of the main settings of cfg for debugging connectivity. Password is
+// masked, durations are formatted as strings. sslmode is derived from TLS config, since pgx does not keep the
+// original value, so "require" is also reported for verify-ca.
+func DumpConfig(cfg *pgxpool.Config) map[string]any {
+	conn := cfg.ConnConfig
+
+	password := ""
+	if conn.Password != "" {
+		password = maskedPassword
+	}
+
+	fallbacks := make([]string, 0, len(conn.Fallbacks))
+	for _, f := range conn.Fallbacks {
+		fallbacks = append(fallbacks, net.JoinHostPort(f.Host, strconv.Itoa(int(f.Port))))
+	}
+
+	return map[string]any{
+		"host":                     conn.Host,
+		"port":                     conn.Port,
+		"database":                 conn.Database,
+		"user":                     conn.User,
+		"password":                 password,
+		"sslmode":                  dumpSSLMode(cfg),
+		"fallbacks":                fallbacks,
+		"connect_timeout":          conn.ConnectTimeout.String(),
+		"runtime_params":           maps.Clone(conn.RuntimeParams),
+		"max_conns":                cfg.MaxConns,
+		"min_conns":                cfg.MinConns,
+		"max_conn_lifetime":        cfg.MaxConnLifetime.String(),
+		"max_conn_lifetime_jitter": cfg.MaxConnLifetimeJitter.String(),
+		"max_conn_idle_time":       cfg.MaxConnIdleTime.String(),
+		"health_check_period":      cfg.HealthCheckPeriod.String(),
+	}
+}
+
+// LogConfig logs settings returned by DumpConfig at debug level. New and other pool constructors call it for every
+// created pool.
+func LogConfig(cfg *pgxpool.Config, log Logger) {
+	dump := DumpConfig(cfg)
+
+	args := make([]any, 0, 2*len(dump))
+	for _, key := range slices.Sorted(maps.Keys(dump)) {
+		args = append(args, key, dump[key])
+	}
+	log.Debug("postgres pool config", args...)
+}
+
+func dumpSSLMode(cfg *pgxpool.Config) string {
+	conn := cfg.ConnConfig
+
+	plainFallback, tlsFallback := false, false
+	for _, f := range conn.Fallbacks {
+		if f.TLSConfig == nil {
+			plainFallback = true
+		} else {
+			tlsFallback = true
+		}
+	}
+
+	switch {
+	case conn.TLSConfig == nil && tlsFallback:
+		return "allow"
+	case conn.TLSConfig == nil:
+		return "disable"
+	case plainFallback:
+		return "prefer"
+	case conn.TLSConfig.InsecureSkipVerify:
+		return "require"
+	default:
+		return "verify-full"
+	}
+}
@@ -0,0 +1,89 @@
+package fx
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PoolConfigFromEnv reads PoolConfig from environment variables PREFIX_DSN, PREFIX_MAX_CONNS, PREFIX_MIN_CONNS,
+// PREFIX_MAX_CONN_LIFETIME, PREFIX_MAX_CONN_IDLE_TIME, PREFIX_HEALTH_CHECK_PERIOD and PREFIX_CONNECT_TIMEOUT. Missing
+// variables keep DefaultPoolConfig values. All parse and validation errors are returned joined together.
+func PoolConfigFromEnv(prefix string) (PoolConfig, error) {
+	cfg := DefaultPoolConfig()
+	key := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return strings.ToUpper(prefix) + "_" + name
+	}
+
+	var errs []error
+
+	cfg.DSN = os.Getenv(key("DSN"))
+	for _, v := range []struct {
+		name string
+		dst  *int32
+	}{
+		{"MAX_CONNS", &cfg.MaxConns},
+		{"MIN_CONNS", &cfg.MinConns},
+	} {
+		if err := lookupEnvInt32(key(v.name), v.dst); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, v := range []struct {
+		name string
+		dst  *time.Duration
+	}{
+		{"MAX_CONN_LIFETIME", &cfg.MaxConnLifetime},
+		{"MAX_CONN_IDLE_TIME", &cfg.MaxConnIdleTime},
+		{"HEALTH_CHECK_PERIOD", &cfg.HealthCheckPeriod},
+		{"CONNECT_TIMEOUT", &cfg.ConnectTimeout},
+	} {
+		if err := lookupEnvDuration(key(v.name), v.dst); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := cfg.ValidateWith(errs...); err != nil {
+		return PoolConfig{}, fmt.Errorf("postgres: read pool config from env: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func lookupEnvInt32(key string, dst *int32) error {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return fmt.Errorf("%s: invalid integer %q", key, value)
+	}
+	if n < 0 {
+		return fmt.Errorf("%s: must not be negative, got %d", key, n)
+	}
+
+	*dst = int32(n)
+	return nil
+}
+
+func lookupEnvDuration(key string, dst *time.Duration) error {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%s: invalid duration %q", key, value)
+	}
+
+	*dst = d
+	return nil
+}
@@ -0,0 +1,111 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RetryExhaustedError is returned by retry functions when they give up calling function. It wraps the last error.
+type RetryExhaustedError struct {
+	// Attempts is the number of calls made.
+	Attempts uint
+	// TotalDuration is the time passed since the first call.
+	TotalDuration time.Duration
+	// Err is the error returned by the last call.
+	Err error
+}
+
+func (e *RetryExhaustedError) Error() string {
+	return fmt.Sprintf("attempter: gave up after %d attempt(s) in %s: %v", e.Attempts, e.TotalDuration, e.Err)
+}
+
+func (e *RetryExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// transientPgCodes are operator intervention codes worth retrying.
+var transientPgCodes = []string{
+	"57P01", // admin_shutdown
+	"57P02", // crash_shutdown
+	"57P03", // cannot_connect_now
+}
+
+// IsTransientPgError reports whether err is worth retrying because connection to the server failed: it wraps
+// *pgconn.PgError of class 08 (connection exception) or operator intervention codes 57P01, 57P02 and 57P03,
+// *pgconn.ConnectError, e.g. connection refused, net.Error timeout, or error reported by pgconn.SafeToRetry, which
+// guarantees nothing was sent to the server. It is suitable as RetryOptions.ShouldRetry.
+func IsTransientPgError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || slices.Contains(transientPgCodes, pgErr.Code)
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return pgconn.SafeToRetry(err)
+}
+
+// retryablePgCodes are transaction rollback codes that usually succeed when transaction is run again.
+var retryablePgCodes = []string{
+	"40001", // serialization_failure
+	"40P01", // deadlock_detected
+}
+
+// PgRetryableErrors reports whether err is worth retrying: transient error reported by IsTransientPgError, including
+// failed connection, serialization failure or deadlock. It can be used as RetryOptions.ShouldRetry.
+var PgRetryableErrors = func(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && slices.Contains(retryablePgCodes, pgErr.Code) {
+		return true
+	}
+
+	return IsTransientPgError(err)
+}
+
+// IsUniqueViolation reports whether err wraps *pgconn.PgError with code 23505 unique_violation.
+func IsUniqueViolation(err error) bool {
+	return hasPgCode(err, "23505")
+}
+
+// IsForeignKeyViolation reports whether err wraps *pgconn.PgError with code 23503 foreign_key_violation.
+func IsForeignKeyViolation(err error) bool {
+	return hasPgCode(err, "23503")
+}
+
+// IsNotNullViolation reports whether err wraps *pgconn.PgError with code 23502 not_null_violation.
+func IsNotNullViolation(err error) bool {
+	return hasPgCode(err, "23502")
+}
+
+// IsCheckViolation reports whether err wraps *pgconn.PgError with code 23514 check_violation.
+func IsCheckViolation(err error) bool {
+	return hasPgCode(err, "23514")
+}
+
+// IsSerializationFailure reports whether err wraps *pgconn.PgError with code 40001 serialization_failure.
+func IsSerializationFailure(err error) bool {
+	return hasPgCode(err, "40001")
+}
+
+// IsDeadlock reports whether err wraps *pgconn.PgError with code 40P01 deadlock_detected.
+func IsDeadlock(err error) bool {
+	return hasPgCode(err, "40P01")
+}
+
+func hasPgCode(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
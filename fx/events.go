@@ -0,0 +1,159 @@
+package fx
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync"
+	"time"
+)
+
+// eventBufferSize is the number of events buffered for every subscriber of EventBus.
+const eventBufferSize = 64
+
+// EventType is kind of pool event published to EventBus.
+type EventType string
+
+const (
+	// EventConnOpened is published when new connection is established.
+	EventConnOpened EventType = "conn_opened"
+	// EventConnClosed is published right before connection is closed.
+	EventConnClosed EventType = "conn_closed"
+	// EventAcquired is published when connection is acquired.
+	EventAcquired EventType = "acquired"
+	// EventAcquireFailed is published when acquire fails, e.g. because context is done.
+	EventAcquireFailed EventType = "acquire_failed"
+	// EventReleased is published when connection is released.
+	EventReleased EventType = "released"
+	// EventHealthCheckFailed is published when ping made in OnStart fails and is retried.
+	EventHealthCheckFailed EventType = "health_check_failed"
+)
+
+// Event is pool event published to EventBus.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Err is the cause of failure events, nil for others.
+	Err error
+}
+
+// EventBus delivers pool events to subscribers. Every subscriber receives events in its own goroutine through
+// buffered channel, so slow subscriber never blocks the pool; events not fitting its buffer are dropped. It is safe for
+// concurrent use.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[EventType]map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	events chan Event
+	once   sync.Once
+}
+
+// NewEventBus returns EventBus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[EventType]map[*subscriber]struct{})}
+}
+
+// Subscribe calls fn for every published event of eventType until returned unsubscribe is called. Events buffered
+// before unsubscribe are still delivered.
+func (b *EventBus) Subscribe(eventType EventType, fn func(Event)) (unsubscribe func()) {
+	s := &subscriber{events: make(chan Event, eventBufferSize)}
+	go func() {
+		for e := range s.events {
+			fn(e)
+		}
+	}()
+
+	b.mu.Lock()
+	if b.subscribers[eventType] == nil {
+		b.subscribers[eventType] = make(map[*subscriber]struct{})
+	}
+	b.subscribers[eventType][s] = struct{}{}
+	b.mu.Unlock()
+
+	return func() {
+		s.once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[eventType], s)
+			b.mu.Unlock()
+			close(s.events)
+		})
+	}
+}
+
+// Publish sends event of eventType to its subscribers without waiting for them.
+func (b *EventBus) Publish(eventType EventType, err error) {
+	e := Event{Type: eventType, Time: time.Now(), Err: err}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subscribers[eventType] {
+		select {
+		case s.events <- e:
+		default:
+		}
+	}
+}
+
+// WithEventBus publishes events of the pool connections, acquires and startup pings to bus.
+func WithEventBus(bus *EventBus) PoolOption {
+	return func(o *poolOptions) error {
+		o.afterConnect = append(o.afterConnect, func(context.Context, *pgx.Conn) error {
+			bus.Publish(EventConnOpened, nil)
+			return nil
+		})
+		o.afterRelease = append(o.afterRelease, func(*pgx.Conn) bool {
+			bus.Publish(EventReleased, nil)
+			return true
+		})
+
+		beforeClose := o.config.BeforeClose
+		o.config.BeforeClose = func(conn *pgx.Conn) {
+			if beforeClose != nil {
+				beforeClose(conn)
+			}
+			bus.Publish(EventConnClosed, nil)
+		}
+
+		o.retry = append(o.retry, func(opts *RetryOptions) {
+			observer := opts.Observer
+			opts.Observer = func(attempt uint, err error, delay time.Duration) {
+				if observer != nil {
+					observer(attempt, err, delay)
+				}
+				bus.Publish(EventHealthCheckFailed, err)
+			}
+		})
+
+		return WithQueryTracer(&eventTracer{bus: bus})(o)
+	}
+}
+
+// eventTracer publishes acquire events. It is pgx.QueryTracer only to be accepted as ConnConfig.Tracer, queries are
+// not traced.
+type eventTracer struct {
+	bus *EventBus
+}
+
+func (t *eventTracer) TraceAcquireStart(
+	ctx context.Context,
+	_ *pgxpool.Pool,
+	_ pgxpool.TraceAcquireStartData,
+) context.Context {
+	return ctx
+}
+
+func (t *eventTracer) TraceAcquireEnd(_ context.Context, _ *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	if data.Err != nil {
+		t.bus.Publish(EventAcquireFailed, data.Err)
+		return
+	}
+	t.bus.Publish(EventAcquired, nil)
+}
+
+func (t *eventTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return ctx
+}
+
+func (t *eventTracer) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
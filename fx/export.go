@@ -0,0 +1,76 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"io"
+)
+
+// CopyFormat is format of data written by BulkExport.
+type CopyFormat string
+
+const (
+	CopyFormatCSV    CopyFormat = "csv"
+	CopyFormatText   CopyFormat = "text"
+	CopyFormatBinary CopyFormat = "binary"
+)
+
+// ExportOption configures BulkExport.
+type ExportOption func(o *exportOptions)
+
+type exportOptions struct {
+	header bool
+}
+
+// WithCopyHeader makes BulkExport write column names as the first line. It is supported only by CopyFormatCSV.
+func WithCopyHeader(header bool) ExportOption {
+	return func(o *exportOptions) {
+		o.header = header
+	}
+}
+
+// BulkExport runs query with COPY TO protocol and streams its result to w in format as it is received, so the result
+// is never held in memory. Copy runs in read-only repeatable read transaction. query is embedded into COPY statement
+// as is, so it must not be built from untrusted input; it can not have parameters.
+func BulkExport(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	query string,
+	w io.Writer,
+	format CopyFormat,
+	opts ...ExportOption,
+) error {
+	var o exportOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	switch format {
+	case CopyFormatCSV, CopyFormatText, CopyFormatBinary:
+	default:
+		return fmt.Errorf("postgres: bulk export: unsupported format %q", format)
+	}
+	if o.header && format != CopyFormatCSV {
+		return errors.New("postgres: bulk export: header is supported only by csv format")
+	}
+
+	sql := "COPY (" + query + ") TO STDOUT WITH (FORMAT " + string(format)
+	if o.header {
+		sql += ", HEADER"
+	}
+	sql += ")"
+
+	err := pgx.BeginTxFunc(ctx, pool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly},
+		func(tx pgx.Tx) error {
+			_, err := tx.Conn().PgConn().CopyTo(ctx, w, sql)
+			return err
+		})
+	if err != nil {
+		return fmt.Errorf("postgres: bulk export: %w", err)
+	}
+
+	return nil
+}
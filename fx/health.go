@@ -0,0 +1,150 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+	"sync"
+	"time"
+)
+
+// HealthCheckerOption configures HealthChecker.
+type HealthCheckerOption func(hc *HealthChecker)
+
+// WithPingTimeout bounds every ping made by HealthChecker. By default ping is bounded by check interval.
+// NewHealthChecker fails if d is not positive.
+func WithPingTimeout(d time.Duration) HealthCheckerOption {
+	return func(hc *HealthChecker) {
+		hc.pingTimeout = d
+	}
+}
+
+// HealthChecker pings pool in background and keeps the latest status.
+type HealthChecker struct {
+	pool        *pgxpool.Pool
+	interval    time.Duration
+	pingTimeout time.Duration
+	log         Logger
+
+	mu        sync.RWMutex
+	checked   bool
+	healthy   bool
+	lastErr   error
+	callbacks []func(healthy bool, err error)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewHealthChecker returns HealthChecker that pings pool every interval from OnStart until OnStop. The first ping is
+// made in OnStart, but its failure does not fail OnStart. Status of the first ping is reported to OnStatusChange
+// callbacks, whether it is healthy or not.
+func NewHealthChecker(
+	lc fx.Lifecycle,
+	pool *pgxpool.Pool,
+	interval time.Duration,
+	log Logger,
+	opts ...HealthCheckerOption,
+) (*HealthChecker, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("health check interval: must be positive, got %s", interval)
+	}
+
+	hc := &HealthChecker{
+		pool:        pool,
+		interval:    interval,
+		pingTimeout: interval,
+		log:         log,
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(hc)
+	}
+	if hc.pingTimeout <= 0 {
+		return nil, fmt.Errorf("ping_timeout: must be positive, got %s", hc.pingTimeout)
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			hc.check(ctx)
+
+			runCtx, cancel := context.WithCancel(context.Background())
+			hc.cancel = cancel
+			go func() {
+				defer close(hc.done)
+				every(runCtx, hc.interval, func() {
+					hc.check(runCtx)
+				})
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if hc.cancel == nil {
+				return nil
+			}
+
+			hc.cancel()
+			select {
+			case <-hc.done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	return hc, nil
+}
+
+// Healthy reports whether the latest ping succeeded.
+func (hc *HealthChecker) Healthy() bool {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.healthy
+}
+
+// LastError returns error of the latest ping or nil if it succeeded.
+func (hc *HealthChecker) LastError() error {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.lastErr
+}
+
+// OnStatusChange registers fn called when pool becomes unhealthy or healthy again. Callbacks are called sequentially
+// from the checking goroutine.
+func (hc *HealthChecker) OnStatusChange(fn func(healthy bool, err error)) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.callbacks = append(hc.callbacks, fn)
+}
+
+func (hc *HealthChecker) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, hc.pingTimeout)
+	defer cancel()
+
+	err := hc.pool.Ping(ctx)
+	healthy := err == nil
+
+	hc.mu.Lock()
+	// the first check reports the initial status, so database that is down at startup is noticed
+	changed := !hc.checked || healthy != hc.healthy
+	hc.checked = true
+	hc.healthy = healthy
+	hc.lastErr = err
+	callbacks := hc.callbacks
+	hc.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if healthy {
+		hc.log.Info("postgres became healthy")
+	} else {
+		hc.log.Warn("postgres became unhealthy", "error", err)
+	}
+	for _, fn := range callbacks {
+		fn(healthy, err)
+	}
+}
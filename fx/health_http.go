@@ -0,0 +1,57 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"net/http"
+	"time"
+)
+
+var errNotChecked = errors.New("postgres: health is not checked yet")
+
+type healthResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NewReadinessHandler returns http.Handler reporting status kept by hc. It responds 200 when pool is healthy and 503
+// otherwise.
+func NewReadinessHandler(hc *HealthChecker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		err := hc.LastError()
+		if err == nil && !hc.Healthy() {
+			err = errNotChecked
+		}
+		writeHealth(w, err)
+	})
+}
+
+// NewSyncHealthHandler returns http.Handler pinging pool on every request. Ping is bounded by timeout when it is
+// positive.
+func NewSyncHealthHandler(pool *pgxpool.Pool, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		writeHealth(w, pool.Ping(ctx))
+	})
+}
+
+func writeHealth(w http.ResponseWriter, err error) {
+	resp := healthResponse{Status: "healthy"}
+	code := http.StatusOK
+	if err != nil {
+		resp = healthResponse{Status: "unhealthy", Error: err.Error()}
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,72 @@
+package fx
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx/fxtest"
+	"testing"
+	"time"
+)
+
+func TestNewHealthCheckerValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		opts     []HealthCheckerOption
+		wantErr  bool
+	}{
+		{name: "valid", interval: time.Second},
+		{name: "valid ping timeout", interval: time.Second, opts: []HealthCheckerOption{WithPingTimeout(time.Millisecond)}},
+		{name: "zero interval", interval: 0, wantErr: true},
+		{name: "negative interval", interval: -time.Second, wantErr: true},
+		{name: "zero ping timeout", interval: time.Second, opts: []HealthCheckerOption{WithPingTimeout(0)}, wantErr: true},
+		{
+			name:     "negative ping timeout",
+			interval: time.Second,
+			opts:     []HealthCheckerOption{WithPingTimeout(-time.Second)},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHealthChecker(fxtest.NewLifecycle(t), nil, tt.interval, NopLogger(), tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHealthCheckerReportsInitialStatus(t *testing.T) {
+	// nothing listens on port 1, so every ping fails
+	pool, err := pgxpool.New(context.Background(), "postgres://user@127.0.0.1:1/db?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	lc := fxtest.NewLifecycle(t)
+	hc, err := NewHealthChecker(lc, pool, time.Hour, NopLogger())
+	if err != nil {
+		t.Fatalf("create health checker: %v", err)
+	}
+
+	var calls []bool
+	hc.OnStatusChange(func(healthy bool, err error) {
+		calls = append(calls, healthy)
+		if err == nil {
+			t.Error("unhealthy status reported without error")
+		}
+	})
+
+	lc.RequireStart()
+	lc.RequireStop()
+
+	if len(calls) != 1 || calls[0] {
+		t.Errorf("status changes = %v, want [false]", calls)
+	}
+	if hc.Healthy() || hc.LastError() == nil {
+		t.Errorf("healthy = %t, last error %v, want unhealthy with error", hc.Healthy(), hc.LastError())
+	}
+}
@@ -0,0 +1,110 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	pgxUUID "github.com/vgarvardt/pgx-google-uuid/v5"
+)
+
+// AfterConnectHook is called for every new connection before it is added to the pool. Returned error refuses the
+// connection.
+type AfterConnectHook func(ctx context.Context, conn *pgx.Conn) error
+
+// WithAfterConnect appends hooks called for every new connection in insertion order. The first failed hook refuses the
+// connection.
+func WithAfterConnect(hooks ...func(ctx context.Context, conn *pgx.Conn) error) PoolOption {
+	return func(o *poolOptions) error {
+		for _, hook := range hooks {
+			o.afterConnect = append(o.afterConnect, hook)
+		}
+		return nil
+	}
+}
+
+// WithBeforeAcquire appends hooks called before connection is acquired from the pool, e.g. to reset session state.
+// Hooks are called in insertion order; if any of them returns false, the connection is destroyed and another one is
+// acquired. Hooks are combined with the ones registered by other options.
+func WithBeforeAcquire(hooks ...func(ctx context.Context, conn *pgx.Conn) bool) PoolOption {
+	return func(o *poolOptions) error {
+		o.beforeAcquire = append(o.beforeAcquire, hooks...)
+		return nil
+	}
+}
+
+// WithAfterRelease appends hooks called when connection is released, before it is returned to the pool. If any of them
+// returns false, the connection is closed instead of being returned. All hooks are called even when an earlier one
+// returns false, so cleanup hooks always run. Hooks run on every release, so they must be cheap.
+func WithAfterRelease(hooks ...func(conn *pgx.Conn) bool) PoolOption {
+	return func(o *poolOptions) error {
+		o.afterRelease = append(o.afterRelease, hooks...)
+		return nil
+	}
+}
+
+// WithoutUUID disables default registration of google/uuid types for new connections.
+func WithoutUUID() PoolOption {
+	return func(o *poolOptions) error {
+		o.withoutUUID = true
+		return nil
+	}
+}
+
+func registerUUID(_ context.Context, conn *pgx.Conn) error {
+	pgxUUID.Register(conn.TypeMap())
+	return nil
+}
+
+// afterConnectHook composes registered hooks into single pgxpool.Config.AfterConnect callback.
+func (o *poolOptions) afterConnectHook() func(ctx context.Context, conn *pgx.Conn) error {
+	hooks := o.afterConnect
+	if !o.withoutUUID {
+		hooks = append([]AfterConnectHook{registerUUID}, hooks...)
+	}
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	return func(ctx context.Context, conn *pgx.Conn) error {
+		for i, hook := range hooks {
+			if err := hook(ctx, conn); err != nil {
+				return fmt.Errorf("postgres: after connect hook %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+}
+
+// beforeAcquireHook composes registered hooks into single pgxpool.Config.BeforeAcquire callback.
+func (o *poolOptions) beforeAcquireHook() func(ctx context.Context, conn *pgx.Conn) bool {
+	hooks := o.beforeAcquire
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	return func(ctx context.Context, conn *pgx.Conn) bool {
+		for _, hook := range hooks {
+			if !hook(ctx, conn) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// afterReleaseHook composes registered hooks into single pgxpool.Config.AfterRelease callback.
+func (o *poolOptions) afterReleaseHook() func(conn *pgx.Conn) bool {
+	hooks := o.afterRelease
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	return func(conn *pgx.Conn) bool {
+		keep := true
+		for _, hook := range hooks {
+			keep = hook(conn) && keep
+		}
+		return keep
+	}
+}
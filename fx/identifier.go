@@ -0,0 +1,45 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidIdentifier is returned by query builders when table or column name is not a plain identifier.
+var ErrInvalidIdentifier = errors.New("postgres: invalid identifier")
+
+var identifierRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// isIdentifier reports whether name is a plain identifier, which is safe to use in SQL without quoting.
+func isIdentifier(name string) bool {
+	return identifierRegexp.MatchString(name)
+}
+
+// parseTableName splits table name, which may be schema qualified, into identifier. Parts that are not plain
+// identifiers are rejected with ErrInvalidIdentifier.
+func parseTableName(table string) (pgx.Identifier, error) {
+	ident := pgx.Identifier(strings.Split(table, "."))
+	if len(ident) > 2 {
+		return nil, fmt.Errorf("%w: table name %q", ErrInvalidIdentifier, table)
+	}
+	for _, part := range ident {
+		if !isIdentifier(part) {
+			return nil, fmt.Errorf("%w: table name %q", ErrInvalidIdentifier, table)
+		}
+	}
+	return ident, nil
+}
+
+// checkIdentifiers returns ErrInvalidIdentifier for the first name that is not a plain identifier. kind tells what
+// the names are, e.g. "column".
+func checkIdentifiers(kind string, names []string) error {
+	for _, name := range names {
+		if !isIdentifier(name) {
+			return fmt.Errorf("%w: %s name %q", ErrInvalidIdentifier, kind, name)
+		}
+	}
+	return nil
+}
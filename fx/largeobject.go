@@ -0,0 +1,99 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"io"
+)
+
+// DefaultLargeObjectChunkSize is the default size of chunks read and written by LargeObjectStore.
+const DefaultLargeObjectChunkSize = 32 * 1024
+
+// LargeObjectOption configures LargeObjectStore.
+type LargeObjectOption func(s *LargeObjectStore)
+
+// WithChunkSize sets size of chunks read and written by LargeObjectStore. Non-positive n keeps the default.
+func WithChunkSize(n int) LargeObjectOption {
+	return func(s *LargeObjectStore) {
+		if n > 0 {
+			s.chunkSize = n
+		}
+	}
+}
+
+// LargeObjectStore streams binary data to and from postgres large objects without loading it into memory. Every call
+// runs in its own transaction, so failed upload leaves no object behind.
+type LargeObjectStore struct {
+	pool      *pgxpool.Pool
+	chunkSize int
+}
+
+// NewLargeObjectStore returns LargeObjectStore using pool.
+func NewLargeObjectStore(pool *pgxpool.Pool, opts ...LargeObjectOption) *LargeObjectStore {
+	s := &LargeObjectStore{pool: pool, chunkSize: DefaultLargeObjectChunkSize}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Upload creates large object with content read from r and returns its oid.
+func (s *LargeObjectStore) Upload(ctx context.Context, r io.Reader) (uint32, error) {
+	var oid uint32
+	err := Transact(ctx, s.pool, func(tx pgx.Tx) error {
+		los := tx.LargeObjects()
+
+		var err error
+		if oid, err = los.Create(ctx, 0); err != nil {
+			return fmt.Errorf("postgres: create large object: %w", err)
+		}
+
+		obj, err := los.Open(ctx, oid, pgx.LargeObjectModeWrite)
+		if err != nil {
+			return fmt.Errorf("postgres: open large object %d: %w", oid, err)
+		}
+
+		// plain structs hide io.WriterTo and io.ReaderFrom, so chunk size is respected
+		if _, err = io.CopyBuffer(struct{ io.Writer }{obj}, struct{ io.Reader }{r}, make([]byte, s.chunkSize)); err != nil {
+			return fmt.Errorf("postgres: write large object %d: %w", oid, err)
+		}
+
+		return obj.Close()
+	})
+	if err != nil {
+		// the object is created in the rolled back transaction, so it does not exist
+		return 0, err
+	}
+
+	return oid, nil
+}
+
+// Download writes content of large object oid to w.
+func (s *LargeObjectStore) Download(ctx context.Context, oid uint32, w io.Writer) error {
+	return Transact(ctx, s.pool, func(tx pgx.Tx) error {
+		los := tx.LargeObjects()
+		obj, err := los.Open(ctx, oid, pgx.LargeObjectModeRead)
+		if err != nil {
+			return fmt.Errorf("postgres: open large object %d: %w", oid, err)
+		}
+
+		if _, err = io.CopyBuffer(struct{ io.Writer }{w}, struct{ io.Reader }{obj}, make([]byte, s.chunkSize)); err != nil {
+			return fmt.Errorf("postgres: read large object %d: %w", oid, err)
+		}
+
+		return obj.Close()
+	})
+}
+
+// Delete removes large object oid.
+func (s *LargeObjectStore) Delete(ctx context.Context, oid uint32) error {
+	return Transact(ctx, s.pool, func(tx pgx.Tx) error {
+		los := tx.LargeObjects()
+		if err := los.Unlink(ctx, oid); err != nil {
+			return fmt.Errorf("postgres: delete large object %d: %w", oid, err)
+		}
+		return nil
+	})
+}
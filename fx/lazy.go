@@ -0,0 +1,179 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+	"sync"
+)
+
+var errLazyPoolStopped = errors.New("postgres: lazy pool is stopped")
+
+// LazyPool is pool that connects to postgres on first use.
+type LazyPool struct {
+	o *poolOptions
+
+	// init is held by goroutine initializing or stopping the pool
+	init chan struct{}
+
+	mu      sync.Mutex
+	pool    *pgxpool.Pool
+	err     error
+	stopped bool
+}
+
+var _ Querier = (*LazyPool)(nil)
+
+// NewLazy parses and validates dbUri and opts like New does, but postpones pool creation and ping until the first call
+// of Acquire, Query, QueryRow or Exec. Failed ping, e.g. because context of the caller is done, is retried by the next
+// call, while invalid pool config fails every following call. OnStop waits for initialization in progress and closes
+// the pool only if it was initialized; calls made after OnStop fail.
+func NewLazy(lc fx.Lifecycle, dbUri string, log Logger, opts ...PoolOption) (*LazyPool, error) {
+	o, err := newPoolOptions("", dbUri, log, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &LazyPool{o: o, init: make(chan struct{}, 1)}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			select {
+			case p.init <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() {
+				<-p.init
+			}()
+
+			p.mu.Lock()
+			p.stopped = true
+			pool := p.pool
+			p.mu.Unlock()
+
+			if pool == nil {
+				return nil
+			}
+			return o.stop(ctx, pool)
+		},
+	})
+
+	return p, nil
+}
+
+// Acquire returns connection from the pool, initializing the pool if needed.
+func (p *LazyPool) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	pool, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.Acquire(ctx)
+}
+
+// Exec acts like pgxpool.Pool.Exec, initializing the pool if needed.
+func (p *LazyPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	pool, err := p.get(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return pool.Exec(ctx, sql, args...)
+}
+
+// Query acts like pgxpool.Pool.Query, initializing the pool if needed.
+func (p *LazyPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	pool, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.Query(ctx, sql, args...)
+}
+
+// QueryRow acts like pgxpool.Pool.QueryRow, initializing the pool if needed.
+func (p *LazyPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	pool, err := p.get(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return pool.QueryRow(ctx, sql, args...)
+}
+
+// SendBatch acts like pgxpool.Pool.SendBatch, initializing the pool if needed.
+func (p *LazyPool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	pool, err := p.get(ctx)
+	if err != nil {
+		return errBatchResults{err: err}
+	}
+	return pool.SendBatch(ctx, b)
+}
+
+// BeginTx acts like pgxpool.Pool.BeginTx, initializing the pool if needed.
+func (p *LazyPool) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	pool, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.BeginTx(ctx, txOptions)
+}
+
+// get returns the pool, initializing it if it is not initialized yet.
+func (p *LazyPool) get(ctx context.Context) (*pgxpool.Pool, error) {
+	if pool, done, err := p.current(); done {
+		return pool, err
+	}
+
+	select {
+	case p.init <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() {
+		<-p.init
+	}()
+
+	// initialized or stopped while waiting
+	if pool, done, err := p.current(); done {
+		return pool, err
+	}
+
+	LogConfig(p.o.config, p.o.log)
+	pool, err := pgxpool.NewWithConfig(context.Background(), p.o.config)
+	if err != nil {
+		err = fmt.Errorf("postgres: init pgxpool %s: %w", MaskDSN(p.o.config.ConnString()), err)
+		p.mu.Lock()
+		p.err = err
+		p.mu.Unlock()
+		return nil, err
+	}
+
+	if err = p.o.start(ctx, pool); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.pool = pool
+	p.mu.Unlock()
+	return pool, nil
+}
+
+// current returns the pool or permanent error, done is false if the pool is to be initialized.
+func (p *LazyPool) current() (pool *pgxpool.Pool, done bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.stopped:
+		return nil, true, errLazyPoolStopped
+	case p.pool != nil:
+		return p.pool, true, nil
+	case p.err != nil:
+		return nil, true, p.err
+	default:
+		return nil, false, nil
+	}
+}
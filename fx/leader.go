@@ -0,0 +1,186 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+	"sync"
+	"time"
+)
+
+// LeaderElector elects single leader among instances sharing the same advisory lock key. Leader holds session level
+// advisory lock on dedicated connection.
+type LeaderElector struct {
+	pool      *pgxpool.Pool
+	key       int64
+	heartbeat time.Duration
+	log       Logger
+
+	mu     sync.Mutex
+	leader bool
+	gain   []func()
+	lose   []func()
+
+	// conn is owned by the election goroutine once it is started, it is held only while being the leader.
+	conn *pgxpool.Conn
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewLeaderElector returns LeaderElector trying to take advisory lock key from OnStart and then every heartbeat.
+// Leader checks its connection every heartbeat and loses leadership when connection is lost or does not answer within
+// heartbeat, so silently dropped session is detected before another instance could act as the leader for long. OnStop
+// releases the lock.
+func NewLeaderElector(
+	lc fx.Lifecycle,
+	pool *pgxpool.Pool,
+	key int64,
+	heartbeat time.Duration,
+	log Logger,
+) (*LeaderElector, error) {
+	if heartbeat <= 0 {
+		return nil, fmt.Errorf("postgres: leader elector heartbeat must be positive, got %s", heartbeat)
+	}
+
+	e := &LeaderElector{
+		pool:      pool,
+		key:       key,
+		heartbeat: heartbeat,
+		log:       log,
+		done:      make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: e.start,
+		OnStop:  e.stop,
+	})
+
+	return e, nil
+}
+
+// IsLeader reports whether this instance currently holds the lock.
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// OnGainLeadership registers fn called when this instance becomes the leader. Callbacks are called sequentially from
+// the election goroutine, so they must not block.
+func (e *LeaderElector) OnGainLeadership(fn func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.gain = append(e.gain, fn)
+}
+
+// OnLoseLeadership registers fn called when this instance stops being the leader, including OnStop.
+func (e *LeaderElector) OnLoseLeadership(fn func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lose = append(e.lose, fn)
+}
+
+func (e *LeaderElector) start(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+
+	go func() {
+		defer close(e.done)
+
+		e.elect(ctx)
+		every(ctx, e.heartbeat, func() {
+			e.elect(ctx)
+		})
+	}()
+
+	return nil
+}
+
+func (e *LeaderElector) stop(ctx context.Context) error {
+	if e.cancel == nil {
+		return nil
+	}
+
+	e.cancel()
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if e.conn == nil {
+		return nil
+	}
+
+	err := advisoryUnlock(ctx, e.conn, e.key)
+	e.conn = nil
+	e.setLeader(false)
+	return err
+}
+
+// elect checks connection of the leader or tries to take the lock. Every round trip is bounded by heartbeat.
+func (e *LeaderElector) elect(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, e.heartbeat)
+	defer cancel()
+
+	if e.conn != nil {
+		if err := e.conn.Ping(ctx); err != nil {
+			if ctx.Err() != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				// stopped
+				return
+			}
+			e.log.Warn("leader lost advisory lock connection", "key", e.key, "error", err)
+			// closing the session releases the lock if server still holds it
+			closeCtx, cancelClose := context.WithTimeout(context.Background(), e.heartbeat)
+			_ = e.conn.Conn().Close(closeCtx)
+			cancelClose()
+			e.conn.Release()
+			e.conn = nil
+			e.setLeader(false)
+		}
+		return
+	}
+
+	conn, err := e.pool.Acquire(ctx)
+	if err != nil {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			e.log.Warn("failed to acquire leader advisory lock connection", "key", e.key, "error", err)
+		}
+		return
+	}
+
+	var acquired bool
+	if err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&acquired); err != nil || !acquired {
+		conn.Release()
+		if err != nil && !errors.Is(ctx.Err(), context.Canceled) {
+			e.log.Warn("failed to try leader advisory lock", "key", e.key, "error", err)
+		}
+		return
+	}
+
+	e.conn = conn
+	e.setLeader(true)
+}
+
+// setLeader updates status and calls callbacks if it is changed.
+func (e *LeaderElector) setLeader(leader bool) {
+	e.mu.Lock()
+	if e.leader == leader {
+		e.mu.Unlock()
+		return
+	}
+	e.leader = leader
+	callbacks := e.lose
+	if leader {
+		callbacks = e.gain
+	}
+	e.mu.Unlock()
+
+	e.log.Info("leadership changed", "key", e.key, "leader", leader)
+	for _, fn := range callbacks {
+		fn()
+	}
+}
@@ -0,0 +1,182 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+const drainPollInterval = 100 * time.Millisecond
+
+// WithDrainTimeout makes OnStop wait up to d for acquired connections to be released before closing the pool. Waiting
+// never exceeds deadline of OnStop context.
+func WithDrainTimeout(d time.Duration) PoolOption {
+	return func(o *poolOptions) error {
+		o.drainTimeout = d
+		return nil
+	}
+}
+
+// WithRetry modifies options of ping retries made in OnStart.
+func WithRetry(opts ...RetryOption) PoolOption {
+	return func(o *poolOptions) error {
+		o.retry = append(o.retry, opts...)
+		return nil
+	}
+}
+
+// WithOnStart appends hooks run sequentially in registration order inside OnStart after successful ping, e.g. to check
+// required extensions are installed. The first failed hook fails OnStart with error wrapped with the hook index.
+func WithOnStart(hooks ...func(ctx context.Context, pool *pgxpool.Pool) error) PoolOption {
+	return func(o *poolOptions) error {
+		for _, hook := range hooks {
+			i := o.onStartHooks
+			o.onStartHooks++
+			o.onStart = append(o.onStart, func(ctx context.Context, pool *pgxpool.Pool) error {
+				if err := hook(ctx, pool); err != nil {
+					return fmt.Errorf("postgres: on start hook %d: %w", i, err)
+				}
+				return nil
+			})
+		}
+		return nil
+	}
+}
+
+// WithOnStop appends hooks run in registration order inside OnStop after the pool is closed, e.g. to deregister
+// service. Failed hooks are logged and do not prevent the next ones from running. OnStop fails with their joined
+// errors only if WithStrictOnStop is set.
+func WithOnStop(hooks ...func(ctx context.Context) error) PoolOption {
+	return func(o *poolOptions) error {
+		o.onStop = append(o.onStop, hooks...)
+		return nil
+	}
+}
+
+// WithStrictOnStop makes errors of WithOnStop hooks fail OnStop.
+func WithStrictOnStop() PoolOption {
+	return func(o *poolOptions) error {
+		o.strictOnStop = true
+		return nil
+	}
+}
+
+// start checks pool is ready to serve queries.
+func (o *poolOptions) start(ctx context.Context, pool *pgxpool.Pool) error {
+	o.log.Info("connecting to postgres")
+	if err := TryWithOptionsCtx(ctx, o.ping(pool), o.retryOptions()); err != nil {
+		return err
+	}
+
+	for _, f := range o.migrations {
+		if err := f(ctx, pool); err != nil {
+			return err
+		}
+	}
+
+	if o.warmup > 0 {
+		o.warm(ctx, pool)
+	}
+
+	for _, f := range o.onStart {
+		if err := f(ctx, pool); err != nil {
+			return err
+		}
+	}
+
+	o.startBackground(pool)
+
+	return nil
+}
+
+// retryOptions returns options of startup ping retries.
+func (o *poolOptions) retryOptions() RetryOptions {
+	opts := DefaultRetryOptions()
+	opts.Logger = o.log
+	for _, opt := range o.retry {
+		opt(&opts)
+	}
+	return opts
+}
+
+// stop releases pool resources.
+func (o *poolOptions) stop(ctx context.Context, pool *pgxpool.Pool) error {
+	o.stopBackground()
+
+	if o.drainTimeout > 0 {
+		o.drain(ctx, pool)
+	}
+
+	o.closePool(pool)
+	o.log.Info("closed postgres client")
+
+	var errs []error
+	for i, hook := range o.onStop {
+		if err := hook(ctx); err != nil {
+			o.log.Error("on stop hook failed", "hook", i, "error", err)
+			errs = append(errs, fmt.Errorf("postgres: on stop hook %d: %w", i, err))
+		}
+	}
+	if o.strictOnStop {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// startBackground runs background functions in separate goroutines.
+func (o *poolOptions) startBackground(pool *pgxpool.Pool) {
+	if len(o.background) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.backgroundCancel = cancel
+
+	for _, f := range o.background {
+		o.backgroundWG.Add(1)
+		go func() {
+			defer o.backgroundWG.Done()
+			f(ctx, pool)
+		}()
+	}
+}
+
+// stopBackground cancels background functions and waits for them to return.
+func (o *poolOptions) stopBackground() {
+	if o.backgroundCancel == nil {
+		return
+	}
+
+	o.backgroundCancel()
+	o.backgroundWG.Wait()
+}
+
+// drain waits until all acquired connections are released, drain timeout elapses or ctx is done.
+func (o *poolOptions) drain(ctx context.Context, pool *pgxpool.Pool) {
+	ctx, cancel := context.WithTimeout(ctx, o.drainTimeout)
+	defer cancel()
+
+	if err := waitReleased(ctx, pool); err != nil {
+		o.log.Warn("closing postgres client with acquired connections",
+			"acquired_conns", pool.Stat().AcquiredConns())
+	}
+}
+
+// waitReleased polls pool until it has no acquired connections or ctx is done.
+func waitReleased(ctx context.Context, pool *pgxpool.Pool) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for pool.Stat().AcquiredConns() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
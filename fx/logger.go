@@ -0,0 +1,66 @@
+package fx
+
+import (
+	"go.uber.org/zap"
+	"log/slog"
+)
+
+// Logger is key-value structured logger used by the package. Arguments alternate between keys and values the same way
+// as in log/slog, so *slog.Logger satisfies it directly.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// ZapLogger adapts l to Logger.
+func ZapLogger(l *zap.Logger) Logger {
+	return zapLogger{l: l.Sugar()}
+}
+
+// SlogLogger adapts l to Logger.
+func SlogLogger(l *slog.Logger) Logger {
+	return l
+}
+
+// NopLogger returns Logger discarding every message.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+type zapLogger struct {
+	l *zap.SugaredLogger
+}
+
+func (z zapLogger) Debug(msg string, args ...any) { z.l.Debugw(msg, args...) }
+func (z zapLogger) Info(msg string, args ...any)  { z.l.Infow(msg, args...) }
+func (z zapLogger) Warn(msg string, args ...any)  { z.l.Warnw(msg, args...) }
+func (z zapLogger) Error(msg string, args ...any) { z.l.Errorw(msg, args...) }
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+// argsLogger prepends args to arguments of every message.
+type argsLogger struct {
+	l    Logger
+	args []any
+}
+
+// loggerWith returns Logger adding args to every message of l.
+func loggerWith(l Logger, args ...any) Logger {
+	return argsLogger{l: l, args: args}
+}
+
+func (a argsLogger) Debug(msg string, args ...any) { a.l.Debug(msg, a.with(args)...) }
+func (a argsLogger) Info(msg string, args ...any)  { a.l.Info(msg, a.with(args)...) }
+func (a argsLogger) Warn(msg string, args ...any)  { a.l.Warn(msg, a.with(args)...) }
+func (a argsLogger) Error(msg string, args ...any) { a.l.Error(msg, a.with(args)...) }
+
+func (a argsLogger) with(args []any) []any {
+	return append(a.args[:len(a.args):len(a.args)], args...)
+}
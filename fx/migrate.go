@@ -0,0 +1,110 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"go.uber.org/fx"
+	"io/fs"
+	"os"
+)
+
+// MigratorOption configures migrations applied by NewMigrator.
+type MigratorOption func(o *migratorOptions)
+
+type migratorOptions struct {
+	fsys fs.FS
+}
+
+// WithEmbeddedMigrations makes NewMigrator read migrations from dir inside fsys instead of the file system, e.g. from
+// embed.FS compiled into the binary.
+func WithEmbeddedMigrations(fsys fs.FS) MigratorOption {
+	return func(o *migratorOptions) {
+		o.fsys = fsys
+	}
+}
+
+// NewMigrator applies goose migrations from dir to pool during OnStart. Failed migration fails OnStart, so partially
+// migrated service never accepts traffic. fx runs OnStart hooks in registration order, so NewMigrator should be invoked
+// before constructors of components relying on the schema. Its hook is appended after the one of the pool, so
+// WithOnStart hooks and background tasks of the pool run before migrations; use WithMigrations when they depend on
+// the schema.
+func NewMigrator(lc fx.Lifecycle, pool *pgxpool.Pool, dir string, log Logger, opts ...MigratorOption) error {
+	fsys, err := migrationsFS(dir, opts)
+	if err != nil {
+		return err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return migrate(ctx, pool, fsys, log)
+		},
+	})
+
+	return nil
+}
+
+// WithMigrations applies goose migrations from dir inside OnStart of the pool right after successful ping, before
+// warmup, WithOnStart hooks and background tasks, regardless of the order of options. Failed migration fails OnStart
+// like in NewMigrator.
+func WithMigrations(dir string, opts ...MigratorOption) PoolOption {
+	return func(o *poolOptions) error {
+		fsys, err := migrationsFS(dir, opts)
+		if err != nil {
+			return err
+		}
+
+		o.migrations = append(o.migrations, func(ctx context.Context, pool *pgxpool.Pool) error {
+			return migrate(ctx, pool, fsys, o.log)
+		})
+		return nil
+	}
+}
+
+func migrationsFS(dir string, opts []MigratorOption) (fs.FS, error) {
+	var o migratorOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.fsys == nil {
+		return os.DirFS(dir), nil
+	}
+	if dir == "" || dir == "." {
+		return o.fsys, nil
+	}
+
+	sub, err := fs.Sub(o.fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: migrations dir %q: %w", dir, err)
+	}
+
+	return sub, nil
+}
+
+func migrate(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS, log Logger) error {
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, fsys)
+	if err != nil {
+		return fmt.Errorf("postgres: init migrations: %w", err)
+	}
+
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres: apply migrations: %w", err)
+	}
+
+	for _, result := range results {
+		log.Info("applied postgres migration",
+			"version", result.Source.Version,
+			"path", result.Source.Path,
+			"duration", result.Duration,
+		)
+	}
+
+	return nil
+}
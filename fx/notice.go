@@ -0,0 +1,38 @@
+package fx
+
+import (
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// WithNoticeHandler sets fn called for every notice sent by server, e.g. by RAISE NOTICE in stored procedures. Without
+// handler notices are dropped.
+func WithNoticeHandler(fn func(*pgconn.Notice)) PoolOption {
+	return func(o *poolOptions) error {
+		o.config.ConnConfig.OnNotice = func(_ *pgconn.PgConn, n *pgconn.Notice) {
+			fn(n)
+		}
+		return nil
+	}
+}
+
+// LogNoticeHandler returns notice handler writing notices to log with level matching notice severity.
+func LogNoticeHandler(log Logger) func(*pgconn.Notice) {
+	return func(n *pgconn.Notice) {
+		severity := n.SeverityUnlocalized
+		if severity == "" {
+			severity = n.Severity
+		}
+
+		args := []any{"severity", severity, "code", n.Code, "message", n.Message, "detail", n.Detail}
+		switch severity {
+		case "DEBUG", "LOG":
+			log.Debug("postgres notice", args...)
+		case "INFO", "NOTICE":
+			log.Info("postgres notice", args...)
+		case "WARNING":
+			log.Warn("postgres notice", args...)
+		default:
+			log.Error("postgres notice", args...)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+	"sync"
+)
+
+// Notifier delivers postgres notifications received over dedicated connection to subscribers.
+type Notifier struct {
+	pool *pgxpool.Pool
+	log  Logger
+
+	mu         sync.Mutex
+	subs       map[string]map[uint64]func(*pgconn.Notification)
+	nextID     uint64
+	dirty      bool
+	waitCancel context.CancelFunc
+
+	// conn and listening are owned by the notification goroutine once it is started.
+	conn      *pgxpool.Conn
+	listening map[string]struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewNotifier returns Notifier that acquires dedicated connection of pool during OnStart and listens to subscribed
+// channels until OnStop. Lost connection is acquired again and all channels are listened again.
+func NewNotifier(lc fx.Lifecycle, pool *pgxpool.Pool, log Logger) (*Notifier, error) {
+	n := &Notifier{
+		pool:      pool,
+		log:       log,
+		subs:      make(map[string]map[uint64]func(*pgconn.Notification)),
+		listening: make(map[string]struct{}),
+		done:      make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: n.start,
+		OnStop:  n.stop,
+	})
+
+	return n, nil
+}
+
+// Subscribe registers fn to be called for every notification on channel. Callbacks are called sequentially from the
+// notification goroutine, so they must not block. Returned function cancels the subscription.
+func (n *Notifier) Subscribe(channel string, fn func(*pgconn.Notification)) (unsubscribe func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	id := n.nextID
+	n.nextID++
+
+	if n.subs[channel] == nil {
+		n.subs[channel] = make(map[uint64]func(*pgconn.Notification))
+	}
+	n.subs[channel][id] = fn
+	n.markDirty()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			delete(n.subs[channel], id)
+			if len(n.subs[channel]) == 0 {
+				delete(n.subs, channel)
+			}
+			n.markDirty()
+		})
+	}
+}
+
+// Notify sends notification with payload to channel.
+func (n *Notifier) Notify(ctx context.Context, channel, payload string) error {
+	if _, err := n.pool.Exec(ctx, "SELECT pg_notify($1, $2)", channel, payload); err != nil {
+		return fmt.Errorf("postgres: notify %q: %w", channel, err)
+	}
+	return nil
+}
+
+func (n *Notifier) start(ctx context.Context) error {
+	conn, err := n.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres: acquire notifier connection: %w", err)
+	}
+	n.conn = conn
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	n.cancel = cancel
+	go n.run(runCtx)
+
+	return nil
+}
+
+func (n *Notifier) stop(ctx context.Context) error {
+	if n.cancel == nil {
+		return nil
+	}
+
+	n.mu.Lock()
+	clear(n.subs)
+	n.mu.Unlock()
+
+	n.cancel()
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// markDirty wakes the notification goroutine to synchronize listened channels. n.mu must be held.
+func (n *Notifier) markDirty() {
+	n.dirty = true
+	if n.waitCancel != nil {
+		n.waitCancel()
+	}
+}
+
+func (n *Notifier) run(ctx context.Context) {
+	defer close(n.done)
+	defer n.releaseConn()
+
+	for ctx.Err() == nil {
+		err := n.listen(ctx)
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+
+		n.log.Warn("postgres notifier connection failed", "error", err)
+		n.releaseConn()
+		_ = sleepCtx(ctx, RetryDelay)
+	}
+}
+
+// releaseConn returns listening connection to the pool. Connection goes back to the pool, so it must not keep
+// listening; if it can not be unlistened, it is closed and the pool destroys it.
+func (n *Notifier) releaseConn() {
+	if n.conn == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), RetryDelay)
+	defer cancel()
+	if _, err := n.conn.Exec(ctx, "UNLISTEN *"); err != nil {
+		n.conn.Conn().Close(ctx)
+	}
+	n.conn.Release()
+	n.conn = nil
+}
+
+// listen waits for notifications until ctx is done or connection fails.
+func (n *Notifier) listen(ctx context.Context) error {
+	if n.conn == nil {
+		conn, err := n.pool.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		n.conn = conn
+		clear(n.listening)
+	}
+
+	for {
+		waitCtx, err := n.sync(ctx)
+		if err != nil {
+			return err
+		}
+
+		notification, err := n.conn.Conn().WaitForNotification(waitCtx)
+		if err != nil {
+			if waitCtx.Err() != nil && !n.conn.Conn().IsClosed() {
+				// woken up by subscription change or stop
+				if ctx.Err() != nil {
+					return nil
+				}
+				continue
+			}
+			return err
+		}
+
+		n.dispatch(notification)
+	}
+}
+
+// sync listens to subscribed channels and unlistens the others. It returns context for waiting that is cancelled on
+// the next subscription change.
+func (n *Notifier) sync(ctx context.Context) (context.Context, error) {
+	for {
+		n.mu.Lock()
+		n.dirty = false
+		var listen, unlisten []string
+		for channel := range n.subs {
+			if _, ok := n.listening[channel]; !ok {
+				listen = append(listen, channel)
+			}
+		}
+		for channel := range n.listening {
+			if _, ok := n.subs[channel]; !ok {
+				unlisten = append(unlisten, channel)
+			}
+		}
+		n.mu.Unlock()
+
+		var errs []error
+		for _, channel := range listen {
+			if _, err := n.conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+				errs = append(errs, fmt.Errorf("postgres: listen %q: %w", channel, err))
+				continue
+			}
+			n.listening[channel] = struct{}{}
+		}
+		for _, channel := range unlisten {
+			if _, err := n.conn.Exec(ctx, "UNLISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+				errs = append(errs, fmt.Errorf("postgres: unlisten %q: %w", channel, err))
+				continue
+			}
+			delete(n.listening, channel)
+		}
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+
+		n.mu.Lock()
+		if n.dirty {
+			n.mu.Unlock()
+			continue
+		}
+		waitCtx, cancel := context.WithCancel(ctx)
+		if n.waitCancel != nil {
+			n.waitCancel()
+		}
+		n.waitCancel = cancel
+		n.mu.Unlock()
+
+		return waitCtx, nil
+	}
+}
+
+func (n *Notifier) dispatch(notification *pgconn.Notification) {
+	n.mu.Lock()
+	fns := make([]func(*pgconn.Notification), 0, len(n.subs[notification.Channel]))
+	for _, fn := range n.subs[notification.Channel] {
+		fns = append(fns, fn)
+	}
+	n.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(notification)
+	}
+}
@@ -0,0 +1,142 @@
+package fx
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync"
+	"time"
+)
+
+// PoolOption configures pool created by New.
+type PoolOption func(o *poolOptions) error
+
+type poolOptions struct {
+	name    string
+	config  *pgxpool.Config
+	log     Logger
+	breaker *CircuitBreaker
+	tracers []pgx.QueryTracer
+
+	maxConnsSet bool
+	minConnsSet bool
+
+	pingFunc func(ctx context.Context, pool *pgxpool.Pool) error
+
+	sslMode   SSLMode
+	tlsConfig *tls.Config
+
+	dialFuncOption string
+	unixSocket     string
+
+	afterConnect  []AfterConnectHook
+	withoutUUID   bool
+	beforeAcquire []func(ctx context.Context, conn *pgx.Conn) bool
+	afterRelease  []func(conn *pgx.Conn) bool
+
+	lenientPrepare bool
+
+	saturationCooldown time.Duration
+
+	drainTimeout  time.Duration
+	closeTimeout  time.Duration
+	acquiredConns *acquiredConns
+	retry         []RetryOption
+	warmup        int32
+
+	// concurrencyLimit is set by WithConcurrencyLimit and accepted only when concurrent is set by NewConcurrent
+	concurrencyLimit int
+	concurrent       bool
+
+	// migrations run sequentially right after successful ping, before warmup and onStart
+	migrations []func(ctx context.Context, pool *pgxpool.Pool) error
+	// onStart functions run sequentially after successful ping and warmup
+	onStart []func(ctx context.Context, pool *pgxpool.Pool) error
+	// onStartHooks counts hooks registered by WithOnStart
+	onStartHooks int
+
+	// onStop functions run sequentially after the pool is closed
+	onStop       []func(ctx context.Context) error
+	strictOnStop bool
+
+	// background functions run from successful OnStart until OnStop
+	background       []func(ctx context.Context, pool *pgxpool.Pool)
+	backgroundCancel context.CancelFunc
+	backgroundWG     sync.WaitGroup
+}
+
+// newPoolOptions parses dbUri and applies opts to the parsed config. Not empty name is attached to every log message.
+func newPoolOptions(name, dbUri string, log Logger, opts []PoolOption) (*poolOptions, error) {
+	configuredPool, err := pgxpool.ParseConfig(dbUri)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing db uri %s: %w", MaskDSN(dbUri), err)
+	}
+
+	if name != "" {
+		log = loggerWith(log, "pool", name)
+	}
+
+	o := &poolOptions{name: name, config: configuredPool, log: log}
+	for _, opt := range opts {
+		if err = opt(o); err != nil {
+			return nil, fmt.Errorf("postgres: apply option: %w", err)
+		}
+	}
+	if o.concurrencyLimit > 0 && !o.concurrent {
+		return nil, errors.New("postgres: WithConcurrencyLimit is supported only by NewConcurrent")
+	}
+
+	o.applyTLS()
+	o.config.AfterConnect = o.afterConnectHook()
+	o.config.BeforeAcquire = o.beforeAcquireHook()
+	o.config.AfterRelease = o.afterReleaseHook()
+
+	switch len(o.tracers) {
+	case 0:
+	case 1:
+		o.config.ConnConfig.Tracer = o.tracers[0]
+	default:
+		o.config.ConnConfig.Tracer = multitracer.New(o.tracers...)
+	}
+
+	return o, nil
+}
+
+// PoolInfo describes pool being configured. It is meant for options implemented outside of this package.
+type PoolInfo struct {
+	// Name is the pool name, empty for unnamed pool.
+	Name string
+	// Log is the logger of the pool, tagged with the pool name.
+	Log Logger
+}
+
+// WithPoolInfo applies option returned by fn called with info of the pool being configured.
+func WithPoolInfo(fn func(info PoolInfo) PoolOption) PoolOption {
+	return func(o *poolOptions) error {
+		return fn(PoolInfo{Name: o.name, Log: o.log})(o)
+	}
+}
+
+// WithOptions applies opts in order, so options implemented outside of this package can be made of several ones.
+func WithOptions(opts ...PoolOption) PoolOption {
+	return func(o *poolOptions) error {
+		for _, opt := range opts {
+			if err := opt(o); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithCircuitBreaker wraps ping made during OnStart with CircuitBreaker configured by cfg.
+func WithCircuitBreaker(cfg CircuitBreakerConfig) PoolOption {
+	return func(o *poolOptions) error {
+		o.breaker = NewCircuitBreaker(cfg.Threshold, cfg.ResetTimeout)
+		return nil
+	}
+}
@@ -0,0 +1,129 @@
+// Package otel registers OpenTelemetry query tracing for pools created by github.com/grbisba/package/pgxpool/fx. It
+// lives in its own module, so OpenTelemetry dependency is pulled only by services that use it.
+package otel
+
+import (
+	"context"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"math/rand"
+)
+
+const (
+	tracerName = "github.com/grbisba/package/pgxpool/fx/otel"
+
+	// DefaultTracingSQLLength is the default number of runes of SQL recorded in db.statement attribute.
+	DefaultTracingSQLLength = 1024
+)
+
+// TracingOption configures tracer registered by WithTracing.
+type TracingOption func(t *queryTracer)
+
+type queryTracer struct {
+	tracer    trace.Tracer
+	sqlLength int
+	sanitize  func(string) string
+	attrs     []attribute.KeyValue
+	// sampleRate is the share of queries traced without recording parent span
+	sampleRate float64
+}
+
+// querySpanKey is context key of span started by queryTracer.
+type querySpanKey struct{}
+
+// WithTracing registers pgx.QueryTracer creating span from tp for every query.
+func WithTracing(tp trace.TracerProvider, opts ...TracingOption) pgxfx.PoolOption {
+	return pgxfx.WithPoolInfo(func(info pgxfx.PoolInfo) pgxfx.PoolOption {
+		t := &queryTracer{
+			tracer:     tp.Tracer(tracerName),
+			sqlLength:  DefaultTracingSQLLength,
+			attrs:      []attribute.KeyValue{attribute.String("db.system", "postgresql")},
+			sampleRate: 1,
+		}
+		if info.Name != "" {
+			t.attrs = append(t.attrs, attribute.String("db.pool.name", info.Name))
+		}
+		for _, opt := range opts {
+			opt(t)
+		}
+
+		return pgxfx.WithQueryTracer(t)
+	})
+}
+
+// WithSampledTracing is WithTracing that creates spans only for sampleRate share of queries, chosen randomly. Queries
+// made within recording span are always traced to keep traces complete. sampleRate is clamped to range [0, 1].
+func WithSampledTracing(tp trace.TracerProvider, sampleRate float64, opts ...TracingOption) pgxfx.PoolOption {
+	return WithTracing(tp, append([]TracingOption{func(t *queryTracer) {
+		t.sampleRate = min(max(sampleRate, 0), 1)
+	}}, opts...)...)
+}
+
+// WithSQLLength sets number of runes of SQL recorded in db.statement attribute. Non-positive n disables truncation.
+func WithSQLLength(n int) TracingOption {
+	return func(t *queryTracer) {
+		t.sqlLength = n
+	}
+}
+
+// WithSanitizeSQL sets function applied to SQL before it is recorded in span, e.g. to redact literal values.
+func WithSanitizeSQL(sanitizer func(string) string) TracingOption {
+	return func(t *queryTracer) {
+		t.sanitize = sanitizer
+	}
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if t.sampleRate < 1 && !trace.SpanFromContext(ctx).IsRecording() && rand.Float64() >= t.sampleRate {
+		return ctx
+	}
+
+	sql := data.SQL
+	if t.sanitize != nil {
+		sql = t.sanitize(sql)
+	}
+
+	ctx, span := t.tracer.Start(ctx, "postgres.query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(t.attrs...),
+		trace.WithAttributes(attribute.String("db.statement", truncate(sql, t.sqlLength))),
+	)
+
+	return context.WithValue(ctx, querySpanKey{}, span)
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	// query skipped by sampling has no span of its own, and span of the caller must not be ended
+	span, ok := ctx.Value(querySpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+		return
+	}
+
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+}
+
+// truncate returns first n runes of s. Non-positive n means no truncation.
+func truncate(s string, n int) string {
+	if n <= 0 {
+		return s
+	}
+
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+
+	return s
+}
@@ -0,0 +1,50 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errNoParallelCalls = errors.New("attempter: no functions to call")
+
+// TryParallel calls every function from fns concurrently and returns nil as soon as any of them succeeds, cancelling
+// context of the remaining ones. If all of them fail or timeout expires first, returned error joins all errors
+// received so far. Zero timeout means that only ctx bounds the calls.
+func TryParallel(ctx context.Context, fns []func(context.Context) error, timeout time.Duration) error {
+	if len(fns) == 0 {
+		return errNoParallelCalls
+	}
+
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	results := make(chan error, len(fns))
+	for _, f := range fns {
+		go func() {
+			results <- f(ctx)
+		}()
+	}
+
+	errs := make([]error, 0, len(fns))
+	for range fns {
+		select {
+		case err := <-results:
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+			return fmt.Errorf("attempter: parallel calls failed: %w", errors.Join(errs...))
+		}
+	}
+
+	return fmt.Errorf("attempter: parallel calls failed: %w", errors.Join(errs...))
+}
@@ -0,0 +1,105 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+	"sync/atomic"
+)
+
+// ErrPoolPaused is returned by PausablePool when it is paused.
+var ErrPoolPaused = errors.New("postgres: pool is paused")
+
+// PausablePool is pool wrapper that can temporarily reject new work without closing the pool.
+type PausablePool struct {
+	pool   *pgxpool.Pool
+	paused atomic.Bool
+}
+
+var _ Querier = (*PausablePool)(nil)
+
+// NewPausablePool wraps pool. OnStop pauses the pool before it is closed: fx runs OnStop hooks in reverse order, so
+// the hook registered here runs before the one closing pool created by New.
+func NewPausablePool(lc fx.Lifecycle, pool *pgxpool.Pool) *PausablePool {
+	p := &PausablePool{pool: pool}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			if err := p.Pause(ctx); err != nil {
+				return fmt.Errorf("postgres: pause pool: %w", err)
+			}
+			return nil
+		},
+	})
+
+	return p
+}
+
+// Pause makes the pool reject new work with ErrPoolPaused and waits until already acquired connections are released
+// or ctx is done. The pool stays paused even if ctx is done first.
+func (p *PausablePool) Pause(ctx context.Context) error {
+	p.paused.Store(true)
+	return waitReleased(ctx, p.pool)
+}
+
+// Resume makes the pool accept new work again.
+func (p *PausablePool) Resume() {
+	p.paused.Store(false)
+}
+
+// IsPaused reports whether the pool is paused.
+func (p *PausablePool) IsPaused() bool {
+	return p.paused.Load()
+}
+
+// Acquire returns connection from the pool unless it is paused.
+func (p *PausablePool) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	if p.IsPaused() {
+		return nil, ErrPoolPaused
+	}
+	return p.pool.Acquire(ctx)
+}
+
+// Exec acts like pgxpool.Pool.Exec unless the pool is paused.
+func (p *PausablePool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if p.IsPaused() {
+		return pgconn.CommandTag{}, ErrPoolPaused
+	}
+	return p.pool.Exec(ctx, sql, args...)
+}
+
+// Query acts like pgxpool.Pool.Query unless the pool is paused.
+func (p *PausablePool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if p.IsPaused() {
+		return nil, ErrPoolPaused
+	}
+	return p.pool.Query(ctx, sql, args...)
+}
+
+// QueryRow acts like pgxpool.Pool.QueryRow unless the pool is paused.
+func (p *PausablePool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if p.IsPaused() {
+		return errRow{err: ErrPoolPaused}
+	}
+	return p.pool.QueryRow(ctx, sql, args...)
+}
+
+// SendBatch acts like pgxpool.Pool.SendBatch unless the pool is paused.
+func (p *PausablePool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	if p.IsPaused() {
+		return errBatchResults{err: ErrPoolPaused}
+	}
+	return p.pool.SendBatch(ctx, b)
+}
+
+// BeginTx acts like pgxpool.Pool.BeginTx unless the pool is paused.
+func (p *PausablePool) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	if p.IsPaused() {
+		return nil, ErrPoolPaused
+	}
+	return p.pool.BeginTx(ctx, txOptions)
+}
@@ -0,0 +1,25 @@
+// Package pgvector registers pgvector types for pools created by github.com/grbisba/package/pgxpool/fx. It lives in
+// its own module, so pgvector-go dependency is pulled only by services that use it.
+package pgvector
+
+import (
+	"context"
+	"fmt"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/jackc/pgx/v5"
+	pgxvec "github.com/pgvector/pgvector-go/pgx"
+)
+
+// WithPgVector appends registration of vector, halfvec and sparsevec types to AfterConnect hooks. Connection is refused
+// with descriptive error when vector extension is not installed, so misconfiguration fails startup ping instead of
+// producing codec errors at query time.
+func WithPgVector() pgxfx.PoolOption {
+	return pgxfx.WithAfterConnect(register)
+}
+
+func register(ctx context.Context, conn *pgx.Conn) error {
+	if err := pgxvec.RegisterTypes(ctx, conn); err != nil {
+		return fmt.Errorf("postgres: register pgvector types (is vector extension installed? run CREATE EXTENSION vector): %w", err)
+	}
+	return nil
+}
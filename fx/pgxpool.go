@@ -2,76 +2,182 @@ package fx
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	pgxUUID "github.com/vgarvardt/pgx-google-uuid/v5"
 	"go.uber.org/fx"
-	"go.uber.org/zap"
-	"time"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 )
 
-const (
-	RetryAttempts = 5
-	RetryDelay    = 3 * time.Second
-)
+// New opens new postgres connection, configures it with opts and return prepared pool.
+func New(lc fx.Lifecycle, dbUri string, log Logger, opts ...PoolOption) (*pgxpool.Pool, error) {
+	return newPool(lc, "", dbUri, log, opts)
+}
+
+// Module provides pool created by New with dbUri and opts as fx module named "postgres". Features enabled by opts,
+// e.g. WithOnStart or WithMigrations, are run as part of the pool lifecycle, so the module is always constructed
+// even if nothing depends on the pool. Logger must be provided by the application.
+func Module(dbUri string, opts ...PoolOption) fx.Option {
+	return fx.Module("postgres",
+		fx.Provide(func(lc fx.Lifecycle, log Logger) (*pgxpool.Pool, error) {
+			return New(lc, dbUri, log, opts...)
+		}),
+		fx.Invoke(func(*pgxpool.Pool) {}),
+	)
+}
+
+// ProvideNamed provides pool created by New with dbUri and opts, tagged with `name:"<name>"`. Pool can be injected with
+// fx.ParamTags or InjectNamed.
+func ProvideNamed(name, dbUri string, opts ...PoolOption) fx.Option {
+	return fx.Provide(
+		fx.Annotate(
+			func(lc fx.Lifecycle, log Logger) (*pgxpool.Pool, error) {
+				return newPool(lc, name, dbUri, log, opts)
+			},
+			fx.ResultTags(nameTag(name)),
+		),
+	)
+}
+
+// PoolSet deduplicates pools provided by its ProvideOnce within one fx.App. Build a new PoolSet for every application
+// and pass it to the modules sharing the database.
+type PoolSet struct {
+	mu   sync.Mutex
+	uris map[string]struct{}
+}
+
+// NewPoolSet returns empty PoolSet.
+func NewPoolSet() *PoolSet {
+	return &PoolSet{uris: make(map[string]struct{})}
+}
+
+// ProvideOnce provides pool created by New with dbUri and opts, like Module does, unless pool for the same database was
+// already provided by ProvideOnce of s. URIs are compared after parsing, so different forms of the same DSN match.
+// Repeated call returns no-op option and its opts are ignored, so the pool provided by the first call is injected.
+func (s *PoolSet) ProvideOnce(dbUri string, opts ...PoolOption) fx.Option {
+	key := normalizeDSN(dbUri)
 
-// New opens new postgres connection, configures it and return prepared pool.
-func New(lc fx.Lifecycle, dbUri string, log *zap.Logger) (*pgxpool.Pool, error) {
-	var pool *pgxpool.Pool
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.uris[key]; ok {
+		return fx.Options()
+	}
+	s.uris[key] = struct{}{}
+
+	return Module(dbUri, opts...)
+}
 
-	configuredPool, err := pgxpool.ParseConfig(dbUri)
+// normalizeDSN returns key identifying database, TLS and session settings of dbUri. Unparsable dbUri is returned as is.
+func normalizeDSN(dbUri string) string {
+	cfg, err := pgxpool.ParseConfig(dbUri)
 	if err != nil {
-		return nil, fmt.Errorf("error while parsing db uri: %w", err)
+		return dbUri
 	}
 
-	configuredPool.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
-		pgxUUID.Register(conn.TypeMap())
-		return nil
+	conn := cfg.ConnConfig
+	hosts := []string{hostKey(conn.Host, conn.Port, conn.TLSConfig)}
+	for _, f := range conn.Fallbacks {
+		hosts = append(hosts, hostKey(f.Host, f.Port, f.TLSConfig))
 	}
 
-	pool, err = pgxpool.NewWithConfig(context.Background(), configuredPool)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s@%s/%s", conn.User, strings.Join(slices.Compact(hosts), ","), conn.Database)
+	for _, key := range slices.Sorted(maps.Keys(conn.RuntimeParams)) {
+		fmt.Fprintf(&b, " %s=%s", key, conn.RuntimeParams[key])
+	}
+	return b.String()
+}
+
+// hostKey describes address and TLS settings of connection attempt made by pgx.
+func hostKey(host string, port uint16, cfg *tls.Config) string {
+	addr := net.JoinHostPort(host, strconv.Itoa(int(port)))
+	if cfg == nil {
+		return addr + "[tls=off]"
+	}
+
+	var certs []string
+	for _, cert := range cfg.Certificates {
+		if len(cert.Certificate) > 0 {
+			sum := sha256.Sum256(cert.Certificate[0])
+			certs = append(certs, hex.EncodeToString(sum[:8]))
+		}
+	}
+	return fmt.Sprintf(
+		"%s[tls=on insecure=%t verify_peer=%t server_name=%s root_cas=%t certs=%s]",
+		addr, cfg.InsecureSkipVerify, cfg.VerifyPeerCertificate != nil, cfg.ServerName, cfg.RootCAs != nil,
+		strings.Join(certs, ","),
+	)
+}
+
+// InjectNamed provides pool named name as untagged *pgxpool.Pool visible only inside fx.Module it is used in, so
+// module constructors can depend on plain *pgxpool.Pool.
+func InjectNamed(name string) fx.Option {
+	return fx.Provide(
+		fx.Annotate(
+			func(pool *pgxpool.Pool) *pgxpool.Pool {
+				return pool
+			},
+			fx.ParamTags(nameTag(name)),
+		),
+		fx.Private,
+	)
+}
+
+func newPool(lc fx.Lifecycle, name, dbUri string, log Logger, opts []PoolOption) (*pgxpool.Pool, error) {
+	pool, _, err := providePool(lc, name, dbUri, log, opts)
+	return pool, err
+}
+
+// providePool creates pool managed by lc and returns its options.
+func providePool(
+	lc fx.Lifecycle,
+	name, dbUri string,
+	log Logger,
+	opts []PoolOption,
+) (*pgxpool.Pool, *poolOptions, error) {
+	pool, o, err := createPool(name, dbUri, log, opts)
 	if err != nil {
-		return nil, fmt.Errorf("postgres: init pgxpool: %w", err)
+		return nil, nil, err
 	}
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			return TryWithAttemptsCtx(ctx, pool.Ping, RetryAttempts, RetryDelay)
+			return o.start(ctx, pool)
 		},
 		OnStop: func(ctx context.Context) error {
-			pool.Close()
-			return nil
+			return o.stop(ctx, pool)
 		},
 	})
 
-	log.Info("created postgres client")
-
-	return pool, nil
+	return pool, o, nil
 }
 
-// TryWithAttempts tries to get non-error result of calling function f with delay.
-func TryWithAttempts(f func() error, attempts uint, delay time.Duration) (err error) {
-	err = f()
-
-	if err == nil {
-		return nil
+// createPool creates pool without connecting it and returns options managing its lifecycle.
+func createPool(name, dbUri string, log Logger, opts []PoolOption) (*pgxpool.Pool, *poolOptions, error) {
+	o, err := newPoolOptions(name, dbUri, log, opts)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	for i := uint(1); i < attempts; i++ {
-		if err = f(); err == nil {
-			return nil
-		}
-		zap.L().Warn("got error in attempter", zap.Uint("attempts", i+1), zap.NamedError("error", err))
-		time.Sleep(delay)
+	LogConfig(o.config, o.log)
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), o.config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("postgres: init pgxpool %s: %w", MaskDSN(dbUri), err)
 	}
-	return err
+
+	o.log.Info("created postgres client")
+
+	return pool, o, nil
 }
 
-// TryWithAttemptsCtx is helper function that calls TryWithAttempts with function f transformed to closure that does not
-// require ctx as necessary argument.
-func TryWithAttemptsCtx(ctx context.Context, f func(context.Context) error, attempts uint, delay time.Duration) (err error) {
-	return TryWithAttempts(func() error {
-		return f(ctx)
-	}, attempts, delay)
+func nameTag(name string) string {
+	return fmt.Sprintf(`name:"%s"`, name)
 }
@@ -0,0 +1,55 @@
+package fx
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WithPingQuery makes OnStart check liveness by executing query instead of pool.Ping. Empty query keeps pool.Ping.
+// Failed queries are retried like pings.
+func WithPingQuery(query string) PoolOption {
+	return func(o *poolOptions) error {
+		if query == "" {
+			o.pingFunc = nil
+			return nil
+		}
+
+		o.pingFunc = func(ctx context.Context, pool *pgxpool.Pool) error {
+			_, err := pool.Exec(ctx, query)
+			return err
+		}
+		return nil
+	}
+}
+
+// WithPingValidator makes OnStart check liveness by querying single row with query and passing it to validate. Error
+// of validate is treated as failed ping and retried.
+func WithPingValidator(query string, validate func(pgx.Row) error) PoolOption {
+	return func(o *poolOptions) error {
+		o.pingFunc = func(ctx context.Context, pool *pgxpool.Pool) error {
+			return validate(pool.QueryRow(ctx, query))
+		}
+		return nil
+	}
+}
+
+// ping returns function checking pool liveness according to options.
+func (o *poolOptions) ping(pool *pgxpool.Pool) func(ctx context.Context) error {
+	ping := pool.Ping
+	if o.pingFunc != nil {
+		ping = func(ctx context.Context) error {
+			return o.pingFunc(ctx, pool)
+		}
+	}
+
+	if o.breaker == nil {
+		return ping
+	}
+
+	return func(ctx context.Context) error {
+		return o.breaker.Do(func() error {
+			return ping(ctx)
+		})
+	}
+}
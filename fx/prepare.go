@@ -0,0 +1,62 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"maps"
+	"slices"
+)
+
+// WithPreparedStatements prepares statements on every new connection, so their first execution does not pay for
+// parsing and planning. stmts maps statement name to SQL. Statements are also prepared in OnStart on a connection
+// from the pool, and failure fails OnStart unless WithLenientPrepare is set.
+func WithPreparedStatements(stmts map[string]string) PoolOption {
+	return func(o *poolOptions) error {
+		names := slices.Sorted(maps.Keys(stmts))
+		for _, name := range names {
+			if !isIdentifier(name) {
+				return fmt.Errorf("prepared statement: invalid name %q", name)
+			}
+		}
+
+		prepare := func(ctx context.Context, conn *pgx.Conn) error {
+			for _, name := range names {
+				if _, err := conn.Prepare(ctx, name, stmts[name]); err != nil {
+					return fmt.Errorf("postgres: prepare statement %s: %w", name, err)
+				}
+			}
+			return nil
+		}
+
+		o.afterConnect = append(o.afterConnect, func(ctx context.Context, conn *pgx.Conn) error {
+			err := prepare(ctx, conn)
+			if err != nil && o.lenientPrepare {
+				o.log.Warn("failed to prepare statements", "error", err)
+				return nil
+			}
+			return err
+		})
+		o.onStart = append(o.onStart, func(ctx context.Context, pool *pgxpool.Pool) error {
+			err := pool.AcquireFunc(ctx, func(conn *pgxpool.Conn) error {
+				return prepare(ctx, conn.Conn())
+			})
+			if err != nil && o.lenientPrepare {
+				o.log.Warn("failed to prepare statements", "error", err)
+				return nil
+			}
+			return err
+		})
+		return nil
+	}
+}
+
+// WithLenientPrepare makes failures of WithPreparedStatements logged instead of failing OnStart or refusing
+// connections.
+func WithLenientPrepare() PoolOption {
+	return func(o *poolOptions) error {
+		o.lenientPrepare = true
+		return nil
+	}
+}
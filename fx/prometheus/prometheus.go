@@ -0,0 +1,177 @@
+// Package prometheus exports statistics of pools created by github.com/grbisba/package/pgxpool/fx as Prometheus
+// metrics. It lives in its own module, so Prometheus client dependency is pulled only by services that use it.
+package prometheus
+
+import (
+	"context"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+)
+
+type metricsCollector struct {
+	acquiredConns *prometheus.Desc
+	idleConns     *prometheus.Desc
+	totalConns    *prometheus.Desc
+	maxConns      *prometheus.Desc
+
+	newConns        prometheus.Counter
+	acquireDuration prometheus.Counter
+
+	mu sync.Mutex
+	// pools maps every exported pool to its last statistics snapshot. Pools sharing labels are summed up.
+	pools map[*pgxpool.Pool]pgxfx.PoolStats
+}
+
+// registerMu serializes adding pools to registered collectors and unregistering them.
+var registerMu sync.Mutex
+
+// NewMetricsCollector returns prometheus.Collector exporting statistics of pool. When poolName is not empty it is
+// attached to every metric as "pool" label.
+func NewMetricsCollector(pool *pgxpool.Pool, namespace, subsystem, poolName string) prometheus.Collector {
+	return newMetricsCollector(pool, namespace, subsystem, poolName)
+}
+
+func newMetricsCollector(pool *pgxpool.Pool, namespace, subsystem, poolName string) *metricsCollector {
+	var labels prometheus.Labels
+	if poolName != "" {
+		labels = prometheus.Labels{"pool": poolName}
+	}
+
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, nil, labels)
+	}
+
+	return &metricsCollector{
+		pools:         map[*pgxpool.Pool]pgxfx.PoolStats{pool: {}},
+		acquiredConns: desc("acquired_conns", "Number of currently acquired connections in the pool."),
+		idleConns:     desc("idle_conns", "Number of currently idle connections in the pool."),
+		totalConns:    desc("total_conns", "Total number of connections currently in the pool."),
+		maxConns:      desc("max_conns", "Maximum size of the pool."),
+		newConns: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "new_conns_total",
+			Help:        "Cumulative count of new connections opened.",
+			ConstLabels: labels,
+		}),
+		acquireDuration: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   subsystem,
+			Name:        "acquire_duration_seconds_total",
+			Help:        "Total duration of all successful acquires from the pool.",
+			ConstLabels: labels,
+		}),
+	}
+}
+
+// ProvideCollector registers metrics collector of pool in reg. It is meant to be used with fx.Invoke.
+func ProvideCollector(pool *pgxpool.Pool, reg prometheus.Registerer) error {
+	return reg.Register(NewMetricsCollector(pool, "pgxpool", "", ""))
+}
+
+// WithMetrics registers metrics collector of the pool in reg during OnStart and unregisters it during OnStop. Pool
+// name, if any, is used as "pool" label. Pools with the same name, including unnamed ones, share the collector and
+// their statistics are summed up.
+func WithMetrics(reg prometheus.Registerer) pgxfx.PoolOption {
+	return pgxfx.WithPoolInfo(func(info pgxfx.PoolInfo) pgxfx.PoolOption {
+		var (
+			c    *metricsCollector
+			pool *pgxpool.Pool
+		)
+		return pgxfx.WithOptions(
+			pgxfx.WithOnStart(func(_ context.Context, p *pgxpool.Pool) error {
+				var err error
+				c, err = registerPool(reg, p, info.Name)
+				pool = p
+				return err
+			}),
+			pgxfx.WithOnStop(func(context.Context) error {
+				if c != nil {
+					unregisterPool(reg, c, pool)
+					c = nil
+				}
+				return nil
+			}),
+		)
+	})
+}
+
+// registerPool registers collector of pool in reg or adds pool to the collector registered before with the same
+// labels.
+func registerPool(reg prometheus.Registerer, pool *pgxpool.Pool, poolName string) (*metricsCollector, error) {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	c, err := registerOrExisting(reg, newMetricsCollector(pool, "pgxpool", "", poolName))
+	if err != nil {
+		return nil, err
+	}
+	c.add(pool)
+	return c, nil
+}
+
+// unregisterPool removes pool from c and unregisters c from reg once it has no pools left.
+func unregisterPool(reg prometheus.Registerer, c *metricsCollector, pool *pgxpool.Pool) {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	if c.remove(pool) == 0 {
+		reg.Unregister(c)
+	}
+}
+
+func (c *metricsCollector) add(pool *pgxpool.Pool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.pools[pool]; !ok {
+		c.pools[pool] = pgxfx.PoolStats{}
+	}
+}
+
+// remove stops exporting statistics of pool and returns the number of pools left.
+func (c *metricsCollector) remove(pool *pgxpool.Pool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.pools, pool)
+	return len(c.pools)
+}
+
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	c.newConns.Describe(ch)
+	c.acquireDuration.Describe(ch)
+}
+
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	var acquired, idle, total, maxConns float64
+
+	c.mu.Lock()
+	for pool, last := range c.pools {
+		stats := pgxfx.SnapshotStats(pool)
+		// pgxpool exposes only cumulative values, so counters are advanced by the difference between snapshots
+		delta := pgxfx.StatsDelta(last, stats)
+		c.newConns.Add(float64(max(delta.NewConnsCount, 0)))
+		c.acquireDuration.Add(max(delta.AcquireDuration, 0).Seconds())
+		c.pools[pool] = stats
+
+		acquired += float64(stats.AcquiredConns)
+		idle += float64(stats.IdleConns)
+		total += float64(stats.TotalConns)
+		maxConns += float64(stats.MaxConns)
+	}
+	c.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, acquired)
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, idle)
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, total)
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, maxConns)
+	c.newConns.Collect(ch)
+	c.acquireDuration.Collect(ch)
+}
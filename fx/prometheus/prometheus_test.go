@@ -0,0 +1,155 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"testing"
+	"time"
+)
+
+func TestRegisterPool(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	first, second := newLazyPool(t), newLazyPool(t)
+
+	// unnamed pools share the collector instead of failing with AlreadyRegisteredError
+	c1, err := registerPool(reg, first, "")
+	if err != nil {
+		t.Fatalf("register first pool: %v", err)
+	}
+	c2, err := registerPool(reg, second, "")
+	if err != nil {
+		t.Fatalf("register second pool: %v", err)
+	}
+	if c1 != c2 {
+		t.Error("unnamed pools got different collectors")
+	}
+	if got := gatherMaxConns(t, reg); got != 8 {
+		t.Errorf("max conns = %v, want sum of both pools 8", got)
+	}
+
+	unregisterPool(reg, c1, first)
+	if got := gatherMaxConns(t, reg); got != 4 {
+		t.Errorf("max conns after first pool stopped = %v, want 4", got)
+	}
+
+	// restarted app registers metrics again
+	unregisterPool(reg, c2, second)
+	if got := gatherMaxConns(t, reg); got != 0 {
+		t.Errorf("max conns after every pool stopped = %v, want no metric", got)
+	}
+	if _, err = registerPool(reg, newLazyPool(t), ""); err != nil {
+		t.Errorf("register after restart: %v", err)
+	}
+}
+
+func TestRegisterPoolNamed(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if _, err := registerPool(reg, newLazyPool(t), "primary"); err != nil {
+		t.Fatalf("register primary pool: %v", err)
+	}
+	c, err := registerPool(reg, newLazyPool(t), "replica")
+	if err != nil {
+		t.Fatalf("register replica pool: %v", err)
+	}
+	if len(c.pools) != 1 {
+		t.Errorf("replica collector exports %d pools, want 1", len(c.pools))
+	}
+}
+
+func newLazyPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	config, err := pgxpool.ParseConfig("postgres://user@localhost/db?pool_max_conns=4")
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// gatherMaxConns returns pgxpool_max_conns metric of reg summed over pools, 0 when it is not registered.
+func gatherMaxConns(t *testing.T, reg *prometheus.Registry) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+
+	var sum float64
+	for _, family := range families {
+		if family.GetName() != "pgxpool_max_conns" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			sum += m.GetGauge().GetValue()
+		}
+	}
+	return sum
+}
+
+func TestWithRetryMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	first, err := WithRetryMetrics(reg, "first")
+	if err != nil {
+		t.Fatalf("WithRetryMetrics: %v", err)
+	}
+	// another retry site shares metrics registered before
+	second, err := WithRetryMetrics(reg, "second")
+	if err != nil {
+		t.Fatalf("WithRetryMetrics of second site: %v", err)
+	}
+
+	opts := pgxfx.NewRetryOptions(first, second, func(o *pgxfx.RetryOptions) {
+		o.Attempts = 2
+		o.InitialDelay = time.Millisecond
+	})
+	calls := 0
+	_ = pgxfx.TryWithOptions(func() error {
+		calls++
+		if calls == 1 {
+			return errors.New("failed")
+		}
+		return nil
+	}, opts)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	got := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "retry_attempts_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			got[labels["name"]+"/"+labels["result"]] = m.GetCounter().GetValue()
+		}
+	}
+	if got["first/success"] != 2 || got["second/success"] != 2 {
+		t.Errorf("retry attempts = %v, want 2 successful calls for each site", got)
+	}
+}
+
+func TestWithRetryMetricsConflict(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	// collector with the same name but different labels
+	reg.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "retry_attempts_total",
+		Help: "Number of calls made by retries.",
+	}, []string{"site"}))
+
+	if _, err := WithRetryMetrics(reg, "first"); err == nil {
+		t.Error("err = nil, want registration conflict")
+	}
+}
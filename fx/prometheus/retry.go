@@ -0,0 +1,52 @@
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// WithRetryMetrics counts calls made by retries in retry_attempts_total counter and observes their total duration in
+// retry_duration_seconds histogram. Both are labeled with name of the retry site and result, which is "success" or
+// "exhausted". Metrics are registered in reg right away and shared by all retry sites using reg. It fails if reg
+// already has different collectors under these names.
+func WithRetryMetrics(reg prometheus.Registerer, name string) (pgxfx.RetryOption, error) {
+	attempts, err := registerOrExisting(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "retry_attempts_total",
+		Help: "Number of calls made by retries.",
+	}, []string{"name", "result"}))
+	if err != nil {
+		return nil, fmt.Errorf("postgres: register retry attempts: %w", err)
+	}
+	duration, err := registerOrExisting(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "retry_duration_seconds",
+		Help:    "Total duration of retries.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"name", "result"}))
+	if err != nil {
+		return nil, fmt.Errorf("postgres: register retry duration: %w", err)
+	}
+
+	return pgxfx.WithRetryFinish(func(calls uint, err error, elapsed time.Duration) {
+		result := "success"
+		if err != nil {
+			result = "exhausted"
+		}
+		attempts.WithLabelValues(name, result).Add(float64(calls))
+		duration.WithLabelValues(name, result).Observe(elapsed.Seconds())
+	}), nil
+}
+
+// registerOrExisting registers c in reg or returns collector registered before in its place.
+func registerOrExisting[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		if existing, ok := already.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	return c, err
+}
@@ -0,0 +1,66 @@
+package fx
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryOne runs sql and scans the first row into T by column names. It returns pgx.ErrNoRows unwrapped when query
+// returns no rows.
+func QueryOne[T any](ctx context.Context, q Querier, sql string, args ...any) (T, error) {
+	var zero T
+
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return zero, err
+	}
+
+	v, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[T])
+	if err != nil {
+		return zero, err
+	}
+
+	return *v, nil
+}
+
+// QueryAll runs sql and scans all rows into slice of T by column names.
+func QueryAll[T any](ctx context.Context, q Querier, sql string, args ...any) ([]T, error) {
+	var result []T
+
+	err := QueryAllCallback(ctx, q, sql, func(v T) error {
+		result = append(result, v)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// QueryAllCallback is QueryEach accepting args after fn.
+func QueryAllCallback[T any](ctx context.Context, q Querier, sql string, fn func(T) error, args ...any) error {
+	return QueryEach(ctx, q, sql, args, fn)
+}
+
+// QueryEach runs sql and scans rows into T by column names one by one, passing each of them to fn without
+// materializing the whole result. Iteration stops at the first error of fn, which is returned.
+func QueryEach[T any](ctx context.Context, q Querier, sql string, args []any, fn func(T) error) error {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := pgx.RowToAddrOfStructByName[T](rows)
+		if err != nil {
+			return err
+		}
+		if err = fn(*v); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
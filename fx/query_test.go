@@ -0,0 +1,140 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"slices"
+	"testing"
+)
+
+// fakeRows is pgx.Rows returning ids as single "id" column. Methods that are not overridden panic.
+type fakeRows struct {
+	pgx.Rows
+
+	ids    []int64
+	pos    int
+	err    error
+	closed bool
+}
+
+func (r *fakeRows) Next() bool {
+	if r.closed || r.pos >= len(r.ids) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	*dest[0].(*int64) = r.ids[r.pos-1]
+	return nil
+}
+
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription {
+	return []pgconn.FieldDescription{{Name: "id"}}
+}
+
+func (r *fakeRows) Err() error {
+	return r.err
+}
+
+func (r *fakeRows) Close() {
+	r.closed = true
+}
+
+// rowsQuerier is Querier returning rows or err from Query.
+type rowsQuerier struct {
+	Querier
+
+	rows *fakeRows
+	err  error
+}
+
+func (q *rowsQuerier) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	return q.rows, nil
+}
+
+func TestQueryEach(t *testing.T) {
+	type row struct {
+		ID int64
+	}
+
+	errStop := errors.New("stop")
+	errQuery := errors.New("query failed")
+	errRows := errors.New("rows failed")
+
+	tests := []struct {
+		name     string
+		q        *rowsQuerier
+		stopAt   int64
+		wantSeen []int64
+		wantErr  error
+	}{
+		{
+			name:     "all rows",
+			q:        &rowsQuerier{rows: &fakeRows{ids: []int64{1, 2, 3}}},
+			wantSeen: []int64{1, 2, 3},
+		},
+		{
+			name:     "fn error stops iteration",
+			q:        &rowsQuerier{rows: &fakeRows{ids: []int64{1, 2, 3, 4, 5}}},
+			stopAt:   3,
+			wantSeen: []int64{1, 2, 3},
+			wantErr:  errStop,
+		},
+		{
+			name:    "query error",
+			q:       &rowsQuerier{err: errQuery},
+			wantErr: errQuery,
+		},
+		{
+			name:     "rows error after iteration",
+			q:        &rowsQuerier{rows: &fakeRows{ids: []int64{1}, err: errRows}},
+			wantSeen: []int64{1},
+			wantErr:  errRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen []int64
+			err := QueryEach(context.Background(), tt.q, "SELECT id FROM t", nil, func(r row) error {
+				seen = append(seen, r.ID)
+				if r.ID == tt.stopAt {
+					return errStop
+				}
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) || tt.wantErr == nil && err != nil {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(seen, tt.wantSeen) {
+				t.Errorf("seen = %v, want %v", seen, tt.wantSeen)
+			}
+			if tt.q.rows != nil && !tt.q.rows.closed {
+				t.Error("rows are not closed")
+			}
+		})
+	}
+}
+
+func TestQueryAll(t *testing.T) {
+	type row struct {
+		ID int64
+	}
+
+	q := &rowsQuerier{rows: &fakeRows{ids: []int64{1, 2}}}
+	got, err := QueryAll[row](context.Background(), q, "SELECT id FROM t")
+	if err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+	if want := []row{{ID: 1}, {ID: 2}}; !slices.Equal(got, want) {
+		t.Errorf("QueryAll = %v, want %v", got, want)
+	}
+}
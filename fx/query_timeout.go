@@ -0,0 +1,71 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+// WithQueryTracer registers tracer called for every query. Several tracers are called in registration order.
+func WithQueryTracer(tracer pgx.QueryTracer) PoolOption {
+	return func(o *poolOptions) error {
+		o.tracers = append(o.tracers, tracer)
+		return nil
+	}
+}
+
+// WithQueryTimeout bounds every query by d when its context has no deadline or a later one, so callers not controlling
+// their context can not hold a connection forever.
+func WithQueryTimeout(d time.Duration) PoolOption {
+	return func(o *poolOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("query_timeout: must be positive, got %s", d)
+		}
+		return WithQueryTracer(&timeoutTracer{timeout: d})(o)
+	}
+}
+
+// errQueryTimeout is the cause of context of query that exceeded timeout set by WithQueryTimeout.
+var errQueryTimeout = errors.New("postgres: query timeout exceeded")
+
+// IsQueryTimeoutError reports whether err returned by query made with ctx is caused by timeout set by
+// WithQueryTimeout, as opposed to deadline or cancellation of ctx itself. pgx does not keep the cause of deadline in
+// returned error, so ctx must be the context passed to the query.
+func IsQueryTimeoutError(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, errQueryTimeout) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || !errors.Is(err, context.DeadlineExceeded) && !pgconn.Timeout(err) {
+		return false
+	}
+
+	// derived context of the query is done with errQueryTimeout cause while ctx of the caller is still alive
+	return ctx.Err() == nil
+}
+
+type timeoutCancelKey struct{}
+
+type timeoutTracer struct {
+	timeout time.Duration
+}
+
+func (t *timeoutTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= t.timeout {
+		return ctx
+	}
+
+	ctx, cancel := context.WithTimeoutCause(ctx, t.timeout, errQueryTimeout)
+	return context.WithValue(ctx, timeoutCancelKey{}, cancel)
+}
+
+func (t *timeoutTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	if cancel, ok := ctx.Value(timeoutCancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
@@ -0,0 +1,133 @@
+package fx
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// DefaultQueryLogSQLLength is the default number of runes of SQL logged by NewQueryLogger.
+const DefaultQueryLogSQLLength = 1024
+
+// LogLevel is level of messages written by NewQueryLogger.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// QueryLoggerOption configures tracer returned by NewQueryLogger.
+type QueryLoggerOption func(l *queryLogger)
+
+// WithTruncateAt sets number of runes of logged SQL. Non-positive n disables truncation.
+func WithTruncateAt(n int) QueryLoggerOption {
+	return func(l *queryLogger) {
+		l.truncateAt = n
+	}
+}
+
+// WithLogArgs adds number of query arguments as args field when n is positive. Argument values are never logged.
+func WithLogArgs(n int) QueryLoggerOption {
+	return func(l *queryLogger) {
+		l.logArgs = n > 0
+	}
+}
+
+// WithLogLevel sets level of logged queries. Failed queries are always logged at error level.
+func WithLogLevel(level LogLevel) QueryLoggerOption {
+	return func(l *queryLogger) {
+		l.level = level
+	}
+}
+
+// WithSlowQueryThreshold makes queries completed faster than d skipped. Zero logs every query.
+func WithSlowQueryThreshold(d time.Duration) QueryLoggerOption {
+	return func(l *queryLogger) {
+		l.slowQueryThreshold = d
+	}
+}
+
+// NewQueryLogger returns pgx.QueryTracer logging completed queries with their SQL, duration in milliseconds, number of
+// affected rows and error. It can be registered with WithQueryTracer.
+func NewQueryLogger(log Logger, opts ...QueryLoggerOption) pgx.QueryTracer {
+	l := &queryLogger{log: log, truncateAt: DefaultQueryLogSQLLength, level: LogLevelDebug}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+type queryLogger struct {
+	log                Logger
+	truncateAt         int
+	logArgs            bool
+	level              LogLevel
+	slowQueryThreshold time.Duration
+}
+
+type queryLogKey struct{}
+
+type queryLogData struct {
+	start time.Time
+	sql   string
+	args  int
+}
+
+func (l *queryLogger) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryLogKey{}, queryLogData{start: time.Now(), sql: data.SQL, args: len(data.Args)})
+}
+
+func (l *queryLogger) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	q, ok := ctx.Value(queryLogKey{}).(queryLogData)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(q.start)
+	if duration < l.slowQueryThreshold {
+		return
+	}
+
+	args := []any{
+		"sql", truncate(q.sql, l.truncateAt),
+		"duration", float64(duration) / float64(time.Millisecond),
+		"rows_affected", data.CommandTag.RowsAffected(),
+	}
+	if l.logArgs {
+		args = append(args, "args", q.args)
+	}
+	if data.Err != nil {
+		l.log.Error("postgres query failed", append(args, "error", data.Err)...)
+		return
+	}
+
+	switch l.level {
+	case LogLevelInfo:
+		l.log.Info("postgres query", args...)
+	case LogLevelWarn:
+		l.log.Warn("postgres query", args...)
+	case LogLevelError:
+		l.log.Error("postgres query", args...)
+	default:
+		l.log.Debug("postgres query", args...)
+	}
+}
+
+// truncate returns first n runes of s. Non-positive n means no truncation.
+func truncate(s string, n int) string {
+	if n <= 0 {
+		return s
+	}
+
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+
+	return s
+}
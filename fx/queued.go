@@ -0,0 +1,93 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned by QueuedPool.Acquire when pool is exhausted and queue reached its maximum depth.
+var ErrQueueFull = errors.New("postgres: acquire queue is full")
+
+// QueuedPool limits the number of callers waiting for a connection of exhausted pool, so overload is reported
+// immediately by ErrQueueFull instead of piling up waiting goroutines.
+type QueuedPool struct {
+	pool         *pgxpool.Pool
+	queue        chan struct{}
+	queueTimeout time.Duration
+
+	mu sync.Mutex
+	// admitted counts callers let through without queueing that have not got their connection yet
+	admitted int32
+}
+
+// NewQueuedPool returns QueuedPool allowing up to maxQueueDepth callers to wait for a connection. Waiting is bounded by
+// queueTimeout if it is positive and by caller context otherwise, so background jobs may wait indefinitely.
+func NewQueuedPool(pool *pgxpool.Pool, maxQueueDepth int, queueTimeout time.Duration) *QueuedPool {
+	return &QueuedPool{
+		pool:         pool,
+		queue:        make(chan struct{}, max(maxQueueDepth, 0)),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Pool returns the wrapped pool.
+func (p *QueuedPool) Pool() *pgxpool.Pool {
+	return p.pool
+}
+
+// QueueDepth returns the number of callers waiting for a connection.
+func (p *QueuedPool) QueueDepth() int {
+	return len(p.queue)
+}
+
+// Acquire acquires connection right away when pool is not exhausted. Otherwise the caller is queued until a
+// connection is released, or gets ErrQueueFull when the queue is full. Callers acquiring connections of the pool
+// directly are not counted, so they can make queued callers wait longer.
+func (p *QueuedPool) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	if p.admit() {
+		defer p.leave()
+		return p.pool.Acquire(ctx)
+	}
+
+	select {
+	case p.queue <- struct{}{}:
+	default:
+		return nil, ErrQueueFull
+	}
+	defer func() {
+		<-p.queue
+	}()
+
+	if p.queueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.queueTimeout)
+		defer cancel()
+	}
+
+	// pgxpool wakes waiting callers as soon as a connection is released
+	return p.pool.Acquire(ctx)
+}
+
+// admit reports whether pool has a free connection for the caller, counting callers admitted before that have not
+// got theirs yet, so burst of callers can not all see the same free connection and bypass the queue.
+func (p *QueuedPool) admit() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stat := p.pool.Stat()
+	if stat.AcquiredConns()+p.admitted >= stat.MaxConns() {
+		return false
+	}
+	p.admitted++
+	return true
+}
+
+// leave is called when caller let through by admit got its connection or failed to.
+func (p *QueuedPool) leave() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.admitted--
+}
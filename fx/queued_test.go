@@ -0,0 +1,45 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueuedPoolBurst(t *testing.T) {
+	// connections are never established, so admitted and queued callers wait until their contexts expire
+	p := NewQueuedPool(newStuckPool(t), 1, 0)
+
+	const callers = 5
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			_, errs[i] = p.Acquire(ctx)
+		}()
+	}
+	wg.Wait()
+
+	full := 0
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, ErrQueueFull):
+			full++
+		case !errors.Is(err, context.DeadlineExceeded):
+			t.Errorf("err = %v, want %v or %v", err, ErrQueueFull, context.DeadlineExceeded)
+		}
+	}
+	// one caller gets the only connection and one waits in the queue
+	if full != callers-2 {
+		t.Errorf("%d callers got %v, want %d", full, ErrQueueFull, callers-2)
+	}
+	if depth := p.QueueDepth(); depth != 0 {
+		t.Errorf("queue depth after callers returned = %d, want 0", depth)
+	}
+}
@@ -0,0 +1,96 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// multirangeServerVersion is server_version_num of PostgreSQL 14, the first version having multiranges.
+const multirangeServerVersion = 140000
+
+// RegisterRangeType returns AfterConnect hook registering range type pgTypeName, its multirange counterpart, e.g.
+// "floatrange" and "floatmultirange", and their array types. Subtype of the range must already be known to conn.
+// Multiranges require PostgreSQL 14 or newer, older servers get only the range and its array type registered.
+// Already registered type is skipped.
+func RegisterRangeType(pgTypeName string) func(ctx context.Context, conn *pgx.Conn) error {
+	return func(ctx context.Context, conn *pgx.Conn) error {
+		if _, ok := conn.TypeMap().TypeForName(pgTypeName); ok {
+			return nil
+		}
+
+		oid, err := lookupTypeOID(ctx, conn, pgTypeName)
+		if errors.Is(err, errTypeNotFound) {
+			return fmt.Errorf("%w, check extension defining it is installed", err)
+		}
+		if err != nil {
+			return err
+		}
+
+		var (
+			subtypeOID    uint32
+			serverVersion int
+		)
+		err = conn.QueryRow(ctx, `SELECT rngsubtype, current_setting('server_version_num')::int
+FROM pg_range
+WHERE rngtypid = $1`, oid).Scan(&subtypeOID, &serverVersion)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("postgres: type %q is not a range", pgTypeName)
+		}
+		if err != nil {
+			return fmt.Errorf("postgres: look up range type %q: %w", pgTypeName, err)
+		}
+
+		subtype, ok := conn.TypeMap().TypeForOID(subtypeOID)
+		if !ok {
+			return fmt.Errorf("postgres: range type %q has unregistered subtype oid %d", pgTypeName, subtypeOID)
+		}
+
+		rangeType := &pgtype.Type{Name: pgTypeName, OID: oid, Codec: &pgtype.RangeCodec{ElementType: subtype}}
+		conn.TypeMap().RegisterType(rangeType)
+		if err := RegisterArrayFor(pgTypeName)(ctx, conn); err != nil {
+			return err
+		}
+		if serverVersion < multirangeServerVersion {
+			return nil
+		}
+
+		var (
+			multirangeOID uint32
+			multirange    string
+		)
+		// rngmultitypid column does not exist before PostgreSQL 14
+		err = conn.QueryRow(ctx, `SELECT rngmultitypid, rngmultitypid::regtype::text
+FROM pg_range
+WHERE rngtypid = $1`, oid).Scan(&multirangeOID, &multirange)
+		if err != nil {
+			return fmt.Errorf("postgres: look up multirange of range type %q: %w", pgTypeName, err)
+		}
+
+		conn.TypeMap().RegisterType(&pgtype.Type{
+			Name:  multirange,
+			OID:   multirangeOID,
+			Codec: &pgtype.MultirangeCodec{ElementType: rangeType},
+		})
+		return RegisterArrayFor(multirange)(ctx, conn)
+	}
+}
+
+// WithRangeTypes registers range types with RegisterRangeType for every new connection.
+func WithRangeTypes(names ...string) PoolOption {
+	registrations := make([]AfterConnectHook, len(names))
+	for i, name := range names {
+		registrations[i] = RegisterRangeType(name)
+	}
+
+	return WithAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		for _, register := range registrations {
+			if err := register(ctx, conn); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,37 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+)
+
+// ReadOnlyPoolName is the name of pool created by NewReadOnlyPool and the name tag used by ProvideReadOnly.
+const ReadOnlyPoolName = "readonly"
+
+// NewReadOnlyPool is New that makes transactions of every connection read-only, so writes fail with SQLSTATE 25006
+// read_only_sql_transaction. Sessions can still turn it off explicitly, so it protects against mistakes only.
+func NewReadOnlyPool(lc fx.Lifecycle, dsn string, log Logger, opts ...PoolOption) (*pgxpool.Pool, error) {
+	return newPool(lc, ReadOnlyPoolName, dsn, log, append([]PoolOption{WithAfterConnect(setReadOnly)}, opts...))
+}
+
+// ProvideReadOnly provides pool created by NewReadOnlyPool, tagged with `name:"readonly"`.
+func ProvideReadOnly(dsn string, opts ...PoolOption) fx.Option {
+	return fx.Provide(
+		fx.Annotate(
+			func(lc fx.Lifecycle, log Logger) (*pgxpool.Pool, error) {
+				return NewReadOnlyPool(lc, dsn, log, opts...)
+			},
+			fx.ResultTags(nameTag(ReadOnlyPoolName)),
+		),
+	)
+}
+
+func setReadOnly(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Exec(ctx, "SET default_transaction_read_only = on"); err != nil {
+		return fmt.Errorf("postgres: set read-only: %w", err)
+	}
+	return nil
+}
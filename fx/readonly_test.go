@@ -0,0 +1,28 @@
+package fx
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"testing"
+)
+
+func TestProvideReadOnly(t *testing.T) {
+	var pool *pgxpool.Pool
+	app := fxtest.New(t,
+		fx.Supply(fx.Annotate(NopLogger(), fx.As(new(Logger)))),
+		ProvideReadOnly("postgres://user@localhost/db", WithoutUUID()),
+		fx.Invoke(fx.Annotate(func(p *pgxpool.Pool) {
+			pool = p
+		}, fx.ParamTags(nameTag(ReadOnlyPoolName)))),
+	)
+	if err := app.Err(); err != nil {
+		t.Fatalf("build app: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	// uuid registration is disabled, so the only hook left is the one making connections read-only
+	if pool.Config().AfterConnect == nil {
+		t.Error("read-only pool has no AfterConnect hook")
+	}
+}
@@ -0,0 +1,174 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+	"sync/atomic"
+)
+
+// ErrNoHealthyReplicas is returned by ReplicaPool.Acquire when no replica can serve connection.
+var ErrNoHealthyReplicas = errors.New("postgres: no healthy replicas")
+
+// ReplicaPool balances connections between pools of several read replicas in round-robin order, skipping replicas
+// that failed the latest health check.
+type ReplicaPool struct {
+	pools   []*pgxpool.Pool
+	opts    []*poolOptions
+	healthy []atomic.Bool
+	started []atomic.Bool
+	next    atomic.Uint64
+	log     Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewReplicaPool creates pool for every dsn configured with the same opts. Their log messages are tagged with
+// "replica-N" pool names. OnStart fails only if no replica can be started. Replicas are pinged every HealthCheckPeriod
+// and taken out of rotation until they recover. Replica that failed to start joins the rotation once it is started by
+// health check. OnStop closes all pools.
+func NewReplicaPool(lc fx.Lifecycle, dsns []string, log Logger, opts ...PoolOption) (*ReplicaPool, error) {
+	if len(dsns) == 0 {
+		return nil, errors.New("postgres: replica pool needs at least one dsn")
+	}
+
+	r := &ReplicaPool{
+		healthy: make([]atomic.Bool, len(dsns)),
+		started: make([]atomic.Bool, len(dsns)),
+		log:     log,
+		done:    make(chan struct{}),
+	}
+	for i, dsn := range dsns {
+		pool, o, err := createPool(fmt.Sprintf("replica-%d", i), dsn, log, opts)
+		if err != nil {
+			r.close()
+			return nil, err
+		}
+		r.pools = append(r.pools, pool)
+		r.opts = append(r.opts, o)
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: r.start,
+		OnStop:  r.stop,
+	})
+
+	return r, nil
+}
+
+// Pools returns pools of all replicas in order of dsns.
+func (r *ReplicaPool) Pools() []*pgxpool.Pool {
+	return r.pools
+}
+
+// Acquire acquires connection from the next healthy replica. If it fails, the following healthy replicas are tried.
+func (r *ReplicaPool) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	start := r.next.Add(1)
+
+	var errs []error
+	for i := range uint64(len(r.pools)) {
+		idx := (start + i) % uint64(len(r.pools))
+		if !r.healthy[idx].Load() {
+			continue
+		}
+
+		conn, err := r.pools[idx].Acquire(ctx)
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, errors.Join(append([]error{ErrNoHealthyReplicas}, errs...)...)
+}
+
+func (r *ReplicaPool) start(ctx context.Context) error {
+	var errs []error
+	for i, pool := range r.pools {
+		if err := r.opts[i].start(ctx, pool); err != nil {
+			r.opts[i].log.Warn("replica is not available", "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		r.started[i].Store(true)
+		r.healthy[i].Store(true)
+	}
+	if len(errs) == len(r.pools) {
+		return errors.Join(append([]error{ErrNoHealthyReplicas}, errs...)...)
+	}
+
+	monitorCtx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go r.monitor(monitorCtx)
+
+	return nil
+}
+
+func (r *ReplicaPool) stop(ctx context.Context) error {
+	var errs []error
+	if r.cancel != nil {
+		r.cancel()
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			r.log.Warn("closing replicas before health check returned", "error", ctx.Err())
+			errs = append(errs, fmt.Errorf("postgres: wait for replica health check: %w", ctx.Err()))
+		}
+	}
+
+	for i, pool := range r.pools {
+		errs = append(errs, r.opts[i].stop(ctx, pool))
+	}
+	return errors.Join(errs...)
+}
+
+// close closes pools created so far.
+func (r *ReplicaPool) close() {
+	for _, pool := range r.pools {
+		pool.Close()
+	}
+}
+
+// monitor pings every replica each health check period and updates its status. Replica that has not been started yet
+// is started once it responds, so it gets into rotation only after OnStart hooks of the pool are run.
+func (r *ReplicaPool) monitor(ctx context.Context) {
+	defer close(r.done)
+
+	period := r.pools[0].Config().HealthCheckPeriod
+	every(ctx, period, func() {
+		for i, pool := range r.pools {
+			pingCtx, cancel := context.WithTimeout(ctx, period)
+			err := pool.Ping(pingCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err == nil && !r.started[i].Load() {
+				if err = r.opts[i].start(ctx, pool); err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					r.opts[i].log.Warn("replica failed to start", "error", err)
+					continue
+				}
+				r.started[i].Store(true)
+			}
+
+			healthy := err == nil
+			if r.healthy[i].Swap(healthy) != healthy {
+				if healthy {
+					r.opts[i].log.Info("replica recovered")
+				} else {
+					r.opts[i].log.Warn("replica is unhealthy", "error", err)
+				}
+			}
+		}
+	})
+}
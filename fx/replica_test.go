@@ -0,0 +1,36 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplicaPoolStopBoundedByCtx(t *testing.T) {
+	pool, o, err := createPool("replica-0", "postgres://user@localhost/db", NopLogger(), nil)
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+
+	// health check that never returns
+	r := &ReplicaPool{
+		opts:   []*poolOptions{o},
+		log:    NopLogger(),
+		cancel: func() {},
+		done:   make(chan struct{}),
+	}
+	r.pools = append(r.pools, pool)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = r.stop(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stop took %s, want it bounded by ctx", elapsed)
+	}
+}
@@ -0,0 +1,230 @@
+package fx
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	RetryAttempts   = 5
+	RetryDelay      = 3 * time.Second
+	RetryMaxDelay   = 10 * time.Second
+	RetryMultiplier = 2
+)
+
+// RetryOptions describes how TryWithOptions retries failed calls.
+type RetryOptions struct {
+	// Attempts is the maximum number of calls. Zero is treated as a single call.
+	Attempts uint
+	// InitialDelay is the delay before the second call.
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between two consecutive calls after Multiplier and jitter are applied, so delays grow
+	// until they reach MaxDelay and stay there. Unlike MaxDuration it does not limit the total time. Zero means no cap.
+	MaxDelay time.Duration
+	// Multiplier is applied to the delay after every failed call. Values below 1 are treated as 1.
+	Multiplier float64
+	// JitterFraction in range [0, 1] adds uniformly distributed random part up to JitterFraction*delay to every delay.
+	// Jitter is applied after Multiplier and before MaxDelay. Zero means no jitter, so delays follow exact schedule.
+	JitterFraction float64
+	// Rand is the source of jitter. Nil means global math/rand source. *rand.Rand is not safe for concurrent use, so
+	// the same Rand must not be shared between concurrent retries.
+	Rand *rand.Rand
+	// MaxDuration caps the total time measured from the first call regardless of remaining attempts. Once it is
+	// exceeded, the last error is returned without sleeping, and the delay before the last call is shortened to fit.
+	// Zero means no cap.
+	MaxDuration time.Duration
+	// ShouldRetry reports whether err is worth another call. Nil means that every error is retried.
+	ShouldRetry func(err error) bool
+	// Logger receives warning about every failed call that is retried. Nil means no logging.
+	Logger Logger
+	// Observer is called right before every sleep between calls with 1-based number of failed call, its error and
+	// actual delay with jitter applied. Nil means no observer.
+	Observer func(attempt uint, err error, delay time.Duration)
+	// OnFinish is called once retries are over with the number of calls made, the returned error and total duration.
+	// Nil means no callback.
+	OnFinish func(attempts uint, err error, duration time.Duration)
+}
+
+// RetryOption modifies RetryOptions.
+type RetryOption func(o *RetryOptions)
+
+// NewRetryOptions returns DefaultRetryOptions modified by opts.
+func NewRetryOptions(opts ...RetryOption) RetryOptions {
+	o := DefaultRetryOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithMaxDuration sets RetryOptions.MaxDuration.
+func WithMaxDuration(d time.Duration) RetryOption {
+	return func(o *RetryOptions) {
+		o.MaxDuration = d
+	}
+}
+
+// WithPgRetryableFilter makes only errors reported by PgRetryableErrors retried.
+func WithPgRetryableFilter() RetryOption {
+	return func(o *RetryOptions) {
+		o.ShouldRetry = PgRetryableErrors
+	}
+}
+
+// WithRetryObserver sets RetryOptions.Observer, which allows to emit metrics or events about retries.
+func WithRetryObserver(fn func(attempt uint, err error, delay time.Duration)) RetryOption {
+	return func(o *RetryOptions) {
+		o.Observer = fn
+	}
+}
+
+// WithRetryFinish sets RetryOptions.OnFinish, calling fn after the callback that is already set, if any.
+func WithRetryFinish(fn func(attempts uint, err error, duration time.Duration)) RetryOption {
+	return func(o *RetryOptions) {
+		prev := o.OnFinish
+		if prev == nil {
+			o.OnFinish = fn
+			return
+		}
+		o.OnFinish = func(attempts uint, err error, duration time.Duration) {
+			prev(attempts, err, duration)
+			fn(attempts, err, duration)
+		}
+	}
+}
+
+// DefaultRetryOptions returns options matching RetryAttempts, RetryDelay, RetryMaxDelay and RetryMultiplier.
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		Attempts:     RetryAttempts,
+		InitialDelay: RetryDelay,
+		MaxDelay:     RetryMaxDelay,
+		Multiplier:   RetryMultiplier,
+	}
+}
+
+// TryWithAttempts tries to get non-error result of calling function f with delay.
+func TryWithAttempts(f func() error, attempts uint, delay time.Duration) (err error) {
+	return TryWithStrategy(f, ConstantStrategy(delay, attempts))
+}
+
+// TryWithAttemptsBackoff tries to get non-error result of calling function f. Delay between attempts starts with
+// initialDelay and is multiplied by multiplier after every failed attempt, but never exceeds maxDelay. Zero maxDelay
+// means that delay is not capped.
+func TryWithAttemptsBackoff(
+	f func() error,
+	attempts uint,
+	initialDelay, maxDelay time.Duration,
+	multiplier float64,
+) (err error) {
+	return TryWithStrategy(f, ExponentialStrategy(initialDelay, maxDelay, multiplier, attempts))
+}
+
+// TryWithAttemptsCtx is helper function that calls TryWithAttempts with function f transformed to closure that does not
+// require ctx as necessary argument. It stops waiting for the next attempt as soon as ctx is done.
+func TryWithAttemptsCtx(ctx context.Context, f func(context.Context) error, attempts uint, delay time.Duration) (err error) {
+	return TryWithStrategyCtx(ctx, f, ConstantStrategy(delay, attempts))
+}
+
+// TryWithAttemptsBackoffCtx is context aware version of TryWithAttemptsBackoff. It stops waiting for the next attempt as
+// soon as ctx is done and returns ctx.Err().
+func TryWithAttemptsBackoffCtx(
+	ctx context.Context,
+	f func(context.Context) error,
+	attempts uint,
+	initialDelay, maxDelay time.Duration,
+	multiplier float64,
+) (err error) {
+	return TryWithStrategyCtx(ctx, f, ExponentialStrategy(initialDelay, maxDelay, multiplier, attempts))
+}
+
+// TryWithOptions tries to get non-error result of calling function f according to opts. When it gives up, the last
+// error is wrapped in *RetryExhaustedError.
+func TryWithOptions(f func() error, opts RetryOptions) error {
+	_, err := TryWithAttemptsDetail(f, opts)
+	return err
+}
+
+// TryWithOptionsCtx is context aware version of TryWithOptions. It stops waiting for the next attempt as soon as ctx is
+// done and returns ctx.Err(). Time left until ctx deadline caps opts.MaxDuration, so retries give up with the last
+// error instead of sleeping past the deadline.
+func TryWithOptionsCtx(ctx context.Context, f func(context.Context) error, opts RetryOptions) error {
+	_, err := TryWithAttemptsCtxDetail(ctx, f, opts)
+	return err
+}
+
+// TryWithAttemptsDetail is TryWithOptions that also returns the number of calls made, which is 1 on first call success.
+func TryWithAttemptsDetail(f func() error, opts RetryOptions) (attemptsUsed uint, err error) {
+	return tryWithStrategy(context.Background(), f, opts, opts.loop())
+}
+
+// TryWithAttemptsCtxDetail is TryWithOptionsCtx that also returns the number of calls made.
+func TryWithAttemptsCtxDetail(
+	ctx context.Context,
+	f func(context.Context) error,
+	opts RetryOptions,
+) (attemptsUsed uint, err error) {
+	loop := opts.loop()
+	if deadline, ok := ctx.Deadline(); ok {
+		// non-positive maxDuration means no cap, so expired deadline still allows a single call
+		remaining := max(time.Until(deadline), time.Nanosecond)
+		if loop.maxDuration <= 0 || remaining < loop.maxDuration {
+			loop.maxDuration = remaining
+		}
+	}
+
+	return tryWithStrategy(ctx, func() error {
+		return f(ctx)
+	}, opts, loop)
+}
+
+// Next implements RetryStrategy.
+func (o RetryOptions) Next(attempt uint, lastErr error) (delay time.Duration, shouldRetry bool) {
+	if attempt >= o.Attempts || o.ShouldRetry != nil && !o.ShouldRetry(lastErr) {
+		return 0, false
+	}
+
+	delay = exponentialDelay(o.InitialDelay, o.MaxDelay, o.Multiplier, attempt)
+	if o.JitterFraction > 0 {
+		delay += time.Duration(o.float64() * min(o.JitterFraction, 1) * float64(delay))
+	}
+	return capDelay(delay, o.MaxDelay), true
+}
+
+func (o RetryOptions) float64() float64 {
+	if o.Rand != nil {
+		return o.Rand.Float64()
+	}
+	return rand.Float64()
+}
+
+// loop returns the part of o that is not covered by RetryStrategy.
+func (o RetryOptions) loop() retryLoop {
+	return retryLoop{
+		maxDuration: o.MaxDuration,
+		logger:      o.Logger,
+		observer:    o.Observer,
+		onFinish:    o.OnFinish,
+	}
+}
+
+// sleepCtx pauses current goroutine for delay or until ctx is done.
+func sleepCtx(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func capDelay(delay, maxDelay time.Duration) time.Duration {
+	if maxDelay > 0 && delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,244 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTryWithAttemptsCtxCancelled(t *testing.T) {
+	tests := []struct {
+		name string
+		try  func(ctx context.Context, f func(context.Context) error) error
+	}{
+		{
+			name: "constant",
+			try: func(ctx context.Context, f func(context.Context) error) error {
+				return TryWithAttemptsCtx(ctx, f, 5, time.Hour)
+			},
+		},
+		{
+			name: "backoff",
+			try: func(ctx context.Context, f func(context.Context) error) error {
+				return TryWithAttemptsBackoffCtx(ctx, f, 5, time.Hour, 0, 2)
+			},
+		},
+		{
+			name: "options",
+			try: func(ctx context.Context, f func(context.Context) error) error {
+				return TryWithOptionsCtx(ctx, f, RetryOptions{Attempts: 5, InitialDelay: time.Hour})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			calls := 0
+			start := time.Now()
+			err := tt.try(ctx, func(context.Context) error {
+				calls++
+				return errors.New("failed")
+			})
+
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want %v", err, context.Canceled)
+			}
+			if calls != 1 {
+				t.Errorf("calls = %d, want 1", calls)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("elapsed = %s, want immediate return", elapsed)
+			}
+		})
+	}
+}
+
+func TestTryWithAttemptsCtxCancelledWhileSleeping(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	err := TryWithAttemptsCtx(ctx, func(context.Context) error {
+		calls++
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return errors.New("failed")
+	}, 5, time.Hour)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestTryWithAttemptsDetail(t *testing.T) {
+	errFailed := errors.New("failed")
+	errFatal := errors.New("fatal")
+
+	tests := []struct {
+		name          string
+		opts          RetryOptions
+		errs          []error
+		wantAttempts  uint
+		wantExhausted bool
+		wantErr       error
+	}{
+		{
+			name:         "first call success",
+			opts:         RetryOptions{Attempts: 3},
+			wantAttempts: 1,
+		},
+		{
+			name:         "success after retries",
+			opts:         RetryOptions{Attempts: 3},
+			errs:         []error{errFailed, errFailed},
+			wantAttempts: 3,
+		},
+		{
+			name:          "exhausted",
+			opts:          RetryOptions{Attempts: 3},
+			errs:          []error{errFailed, errFailed, errFailed},
+			wantAttempts:  3,
+			wantExhausted: true,
+			wantErr:       errFailed,
+		},
+		{
+			name:          "zero attempts make single call",
+			opts:          RetryOptions{},
+			errs:          []error{errFailed},
+			wantAttempts:  1,
+			wantExhausted: true,
+			wantErr:       errFailed,
+		},
+		{
+			name: "error that should not be retried",
+			opts: RetryOptions{Attempts: 5, ShouldRetry: func(err error) bool {
+				return !errors.Is(err, errFatal)
+			}},
+			errs:          []error{errFailed, errFatal},
+			wantAttempts:  2,
+			wantExhausted: true,
+			wantErr:       errFatal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, detail := range []struct {
+				name string
+				try  func(f func() error) (uint, error)
+			}{
+				{name: "plain", try: func(f func() error) (uint, error) {
+					return TryWithAttemptsDetail(f, tt.opts)
+				}},
+				{name: "ctx", try: func(f func() error) (uint, error) {
+					return TryWithAttemptsCtxDetail(context.Background(), func(context.Context) error {
+						return f()
+					}, tt.opts)
+				}},
+			} {
+				t.Run(detail.name, func(t *testing.T) {
+					calls := 0
+					attempts, err := detail.try(func() error {
+						calls++
+						if calls <= len(tt.errs) {
+							return tt.errs[calls-1]
+						}
+						return nil
+					})
+
+					if attempts != tt.wantAttempts || uint(calls) != tt.wantAttempts {
+						t.Errorf("attempts = %d, calls = %d, want %d", attempts, calls, tt.wantAttempts)
+					}
+
+					var exhausted *RetryExhaustedError
+					if errors.As(err, &exhausted) != tt.wantExhausted {
+						t.Fatalf("err = %v, want exhausted %t", err, tt.wantExhausted)
+					}
+					if tt.wantExhausted && exhausted.Attempts != tt.wantAttempts {
+						t.Errorf("exhausted attempts = %d, want %d", exhausted.Attempts, tt.wantAttempts)
+					}
+					if !errors.Is(err, tt.wantErr) {
+						t.Errorf("err = %v, want %v", err, tt.wantErr)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestRetryFinish(t *testing.T) {
+	var got []string
+	opts := NewRetryOptions(
+		func(o *RetryOptions) {
+			o.Attempts = 2
+			o.InitialDelay = time.Millisecond
+		},
+		WithRetryFinish(func(attempts uint, err error, _ time.Duration) {
+			got = append(got, "first")
+			if attempts != 2 || err == nil {
+				t.Errorf("first callback got attempts %d, err %v", attempts, err)
+			}
+		}),
+		WithRetryFinish(func(uint, error, time.Duration) {
+			got = append(got, "second")
+		}),
+	)
+
+	_ = TryWithOptions(func() error { return errors.New("failed") }, opts)
+
+	if len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Errorf("callbacks = %v, want [first second]", got)
+	}
+}
+
+func TestTryWithOptionsCtxDeadlineCapsMaxDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		deadline time.Duration
+		want     time.Duration
+	}{
+		{name: "deadline shorter than max duration", deadline: 5 * time.Second, want: 5 * time.Second},
+		{name: "deadline longer than max duration", deadline: time.Minute, want: 10 * time.Second},
+		{name: "no deadline", want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctx := parent
+			if tt.deadline > 0 {
+				var cancelTimeout context.CancelFunc
+				ctx, cancelTimeout = context.WithTimeout(parent, tt.deadline)
+				defer cancelTimeout()
+			}
+
+			// the first delay is cut to the effective max duration; cancel instead of waiting it out
+			var got time.Duration
+			err := TryWithOptionsCtx(ctx, func(context.Context) error {
+				return errors.New("failed")
+			}, RetryOptions{
+				Attempts:     2,
+				InitialDelay: time.Hour,
+				MaxDuration:  10 * time.Second,
+				Observer: func(_ uint, _ error, delay time.Duration) {
+					got = delay
+					cancel()
+				},
+			})
+
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want %v", err, context.Canceled)
+			}
+			if got > tt.want || got < tt.want-time.Second {
+				t.Errorf("delay = %s, want about %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSaturationWarningCooldown is the minimum interval between warnings logged by WithSaturationWarning.
+const DefaultSaturationWarningCooldown = 5 * time.Second
+
+// WithSaturationWarning logs warning on acquire when share of acquired connections exceeds threshold in range (0, 1].
+// Warnings are logged at most once per DefaultSaturationWarningCooldown or interval set by
+// WithSaturationWarningCooldown. Acquires made before OnStart are not checked.
+func WithSaturationWarning(threshold float64) PoolOption {
+	return func(o *poolOptions) error {
+		if threshold <= 0 || threshold > 1 {
+			return fmt.Errorf("saturation warning: threshold must be in range (0, 1], got %v", threshold)
+		}
+
+		var (
+			started  atomic.Pointer[pgxpool.Pool]
+			lastWarn atomic.Int64
+		)
+		o.onStart = append(o.onStart, func(_ context.Context, pool *pgxpool.Pool) error {
+			started.Store(pool)
+			return nil
+		})
+
+		o.beforeAcquire = append(o.beforeAcquire, func(_ context.Context, _ *pgx.Conn) bool {
+			pool := started.Load()
+			if pool == nil {
+				return true
+			}
+
+			stat := pool.Stat()
+			if stat.MaxConns() == 0 || float64(stat.AcquiredConns())/float64(stat.MaxConns()) <= threshold {
+				return true
+			}
+
+			cooldown := o.saturationCooldown
+			if cooldown <= 0 {
+				cooldown = DefaultSaturationWarningCooldown
+			}
+			now := time.Now().UnixNano()
+			last := lastWarn.Load()
+			if last != 0 && now-last < int64(cooldown) || !lastWarn.CompareAndSwap(last, now) {
+				return true
+			}
+
+			o.log.Warn("postgres pool is near saturation",
+				"acquired_conns", stat.AcquiredConns(),
+				"max_conns", stat.MaxConns(),
+				"threshold", threshold,
+			)
+			return true
+		})
+		return nil
+	}
+}
+
+// WithSaturationWarningCooldown sets the minimum interval between warnings logged by WithSaturationWarning.
+func WithSaturationWarningCooldown(d time.Duration) PoolOption {
+	return func(o *poolOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("saturation warning cooldown: must be positive, got %s", d)
+		}
+		o.saturationCooldown = d
+		return nil
+	}
+}
@@ -0,0 +1,46 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"sync/atomic"
+)
+
+var savepointCounter atomic.Uint64
+
+// Savepoint sets savepoint name in tx and calls fn. Savepoint is released when fn returns nil and rolled back to
+// otherwise, so tx can continue. Errors of fn and rollback are joined. Empty name is replaced with unique generated
+// one, e.g. sp_1, which allows nesting.
+func Savepoint(ctx context.Context, tx pgx.Tx, name string, fn func(pgx.Tx) error) (err error) {
+	if name == "" {
+		name = "sp_" + strconv.FormatUint(savepointCounter.Add(1), 10)
+	}
+	ident := pgx.Identifier{name}.Sanitize()
+
+	if _, err = tx.Exec(ctx, "SAVEPOINT "+ident); err != nil {
+		return fmt.Errorf("postgres: set savepoint %s: %w", name, err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = tx.Exec(ctx, "ROLLBACK TO SAVEPOINT "+ident)
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if _, rbErr := tx.Exec(ctx, "ROLLBACK TO SAVEPOINT "+ident); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("postgres: rollback to savepoint %s: %w", name, rbErr))
+		}
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, "RELEASE SAVEPOINT "+ident); err != nil {
+		return fmt.Errorf("postgres: release savepoint %s: %w", name, err)
+	}
+
+	return nil
+}
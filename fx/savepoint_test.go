@@ -0,0 +1,135 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSavepoint(t *testing.T) {
+	errFn := errors.New("fn failed")
+	errDB := errors.New("db failed")
+
+	tests := []struct {
+		name      string
+		execErr   map[string]error
+		fnErr     error
+		wantCalls []string
+		wantErrs  []error
+	}{
+		{
+			name:      "release on success",
+			wantCalls: []string{`SAVEPOINT "sp"`, "UPDATE", `RELEASE SAVEPOINT "sp"`},
+		},
+		{
+			name:      "rollback on fn error",
+			fnErr:     errFn,
+			wantCalls: []string{`SAVEPOINT "sp"`, "UPDATE", `ROLLBACK TO SAVEPOINT "sp"`},
+			wantErrs:  []error{errFn},
+		},
+		{
+			name:      "rollback failure is joined",
+			execErr:   map[string]error{`ROLLBACK TO SAVEPOINT "sp"`: errDB},
+			fnErr:     errFn,
+			wantCalls: []string{`SAVEPOINT "sp"`, "UPDATE", `ROLLBACK TO SAVEPOINT "sp"`},
+			wantErrs:  []error{errFn, errDB},
+		},
+		{
+			name:      "savepoint failure",
+			execErr:   map[string]error{`SAVEPOINT "sp"`: errDB},
+			wantCalls: []string{`SAVEPOINT "sp"`},
+			wantErrs:  []error{errDB},
+		},
+		{
+			name:      "release failure",
+			execErr:   map[string]error{`RELEASE SAVEPOINT "sp"`: errDB},
+			wantCalls: []string{`SAVEPOINT "sp"`, "UPDATE", `RELEASE SAVEPOINT "sp"`},
+			wantErrs:  []error{errDB},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTx{execErr: tt.execErr}
+			err := Savepoint(context.Background(), tx, "sp", func(tx pgx.Tx) error {
+				_, _ = tx.Exec(context.Background(), "UPDATE")
+				return tt.fnErr
+			})
+
+			if len(tt.wantErrs) == 0 && err != nil {
+				t.Errorf("err = %v, want nil", err)
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("err = %v, want wrapped %v", err, want)
+				}
+			}
+			if !slices.Equal(tx.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", tx.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSavepointNested(t *testing.T) {
+	errInner := errors.New("inner failed")
+	tx := &fakeTx{}
+
+	err := Savepoint(context.Background(), tx, "", func(tx pgx.Tx) error {
+		innerErr := Savepoint(context.Background(), tx, "", func(tx pgx.Tx) error {
+			_, _ = tx.Exec(context.Background(), "INSERT")
+			return errInner
+		})
+		if !errors.Is(innerErr, errInner) {
+			t.Errorf("inner err = %v, want %v", innerErr, errInner)
+		}
+		_, _ = tx.Exec(context.Background(), "UPDATE")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("outer err = %v, want nil", err)
+	}
+
+	if len(tx.calls) != 6 {
+		t.Fatalf("calls = %v, want 6 statements", tx.calls)
+	}
+	outer := strings.TrimPrefix(tx.calls[0], "SAVEPOINT ")
+	inner := strings.TrimPrefix(tx.calls[1], "SAVEPOINT ")
+	if outer == inner || !strings.HasPrefix(outer, `"sp_`) || !strings.HasPrefix(inner, `"sp_`) {
+		t.Fatalf("generated savepoint names %s and %s, want distinct sp_N names", outer, inner)
+	}
+
+	want := []string{
+		"SAVEPOINT " + outer,
+		"SAVEPOINT " + inner,
+		"INSERT",
+		"ROLLBACK TO SAVEPOINT " + inner,
+		"UPDATE",
+		"RELEASE SAVEPOINT " + outer,
+	}
+	if !slices.Equal(tx.calls, want) {
+		t.Errorf("calls = %v, want %v", tx.calls, want)
+	}
+}
+
+func TestSavepointPanic(t *testing.T) {
+	tx := &fakeTx{}
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("recovered %v, want boom", p)
+		}
+		want := []string{`SAVEPOINT "sp"`, `ROLLBACK TO SAVEPOINT "sp"`}
+		if !slices.Equal(tx.calls, want) {
+			t.Errorf("calls = %v, want %v", tx.calls, want)
+		}
+	}()
+
+	_ = Savepoint(context.Background(), tx, "sp", func(pgx.Tx) error {
+		panic("boom")
+	})
+	t.Errorf("panic was not propagated")
+}
@@ -0,0 +1,66 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GetMigrationVersion returns the highest version recorded in migrations table, which may be schema qualified. The
+// version is read from version column, or from version_id column used by goose, e.g. goose_db_version table written
+// by NewMigrator. It returns 0 when the table does not exist or is empty.
+func GetMigrationVersion(ctx context.Context, pool *pgxpool.Pool, table string) (int64, error) {
+	ident, err := parseTableName(table)
+	if err != nil {
+		return 0, err
+	}
+
+	var column *string
+	err = pool.QueryRow(ctx, `SELECT attname::text
+FROM pg_attribute
+WHERE attrelid = to_regclass($1) AND attname IN ('version', 'version_id') AND attnum > 0 AND NOT attisdropped
+ORDER BY attname = 'version' DESC
+LIMIT 1`, ident.Sanitize()).Scan(&column)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		if err = pool.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", ident.Sanitize()).Scan(&exists); err != nil {
+			return 0, fmt.Errorf("postgres: migration version of %s: %w", table, err)
+		}
+		if !exists {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("postgres: migration version of %s: table has no version column", table)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("postgres: migration version of %s: %w", table, err)
+	}
+
+	var version *int64
+	sql := "SELECT max(" + pgx.Identifier{*column}.Sanitize() + ")::bigint FROM " + ident.Sanitize()
+	if err = pool.QueryRow(ctx, sql).Scan(&version); err != nil {
+		return 0, fmt.Errorf("postgres: migration version of %s: %w", table, err)
+	}
+	if version == nil {
+		return 0, nil
+	}
+
+	return *version, nil
+}
+
+// RequireSchemaVersion fails unless migration version read by GetMigrationVersion from table is in range [min, max],
+// e.g. to prevent service from starting on incompatible schema.
+func RequireSchemaVersion(ctx context.Context, pool *pgxpool.Pool, table string, min, max int64) error {
+	version, err := GetMigrationVersion(ctx, pool, table)
+	if err != nil {
+		return err
+	}
+
+	if version < min || version > max {
+		return fmt.Errorf("postgres: schema version %d of %s is outside of supported range [%d, %d]",
+			version, table, min, max)
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ Querier = (*SelfHealingPool)(nil)
+
+// SelfHealingPool replaces pool built by constructor with a fresh one after several consecutive failed pings, e.g.
+// when pool can not recover after database restart.
+type SelfHealingPool struct {
+	pool             atomic.Pointer[pgxpool.Pool]
+	constructor      func() (*pgxpool.Pool, error)
+	failureThreshold uint
+	log              Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	// closing tracks replaced pools waiting for acquired connections to be released
+	closing sync.WaitGroup
+}
+
+// NewSelfHealingPool builds pool with constructor and pings it every pool HealthCheckPeriod from OnStart until OnStop.
+// Every ping is bounded by HealthCheckPeriod too. After failureThreshold consecutive failures pool is swapped with a
+// new one built by constructor. Operations already running on the replaced pool complete before it is closed. OnStop
+// closes the current pool and waits for replaced ones until its ctx is done.
+func NewSelfHealingPool(
+	lc fx.Lifecycle,
+	constructor func() (*pgxpool.Pool, error),
+	failureThreshold uint,
+	log Logger,
+) (*SelfHealingPool, error) {
+	if failureThreshold == 0 {
+		return nil, errors.New("postgres: self healing pool failure threshold must be positive")
+	}
+
+	pool, err := constructor()
+	if err != nil {
+		return nil, fmt.Errorf("postgres: construct pool: %w", err)
+	}
+
+	p := &SelfHealingPool{
+		constructor:      constructor,
+		failureThreshold: failureThreshold,
+		log:              log,
+		done:             make(chan struct{}),
+	}
+	p.pool.Store(pool)
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			p.cancel = cancel
+			go p.monitor(ctx)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if p.cancel != nil {
+				p.cancel()
+				select {
+				case <-p.done:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			// Close waits for acquired connections to be released, so it is bounded by ctx
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				p.Pool().Close()
+				p.closing.Wait()
+			}()
+
+			select {
+			case <-closed:
+				return nil
+			case <-ctx.Done():
+				p.log.Warn("self healing pool is still closing in background", "error", ctx.Err())
+				return ctx.Err()
+			}
+		},
+	})
+
+	return p, nil
+}
+
+// Pool returns the current pool. It must not be kept for long, as it can be replaced.
+func (p *SelfHealingPool) Pool() *pgxpool.Pool {
+	return p.pool.Load()
+}
+
+// Acquire acts like pgxpool.Pool.Acquire on the current pool.
+func (p *SelfHealingPool) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	return p.Pool().Acquire(ctx)
+}
+
+// Exec acts like pgxpool.Pool.Exec on the current pool.
+func (p *SelfHealingPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return p.Pool().Exec(ctx, sql, args...)
+}
+
+// Query acts like pgxpool.Pool.Query on the current pool.
+func (p *SelfHealingPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return p.Pool().Query(ctx, sql, args...)
+}
+
+// QueryRow acts like pgxpool.Pool.QueryRow on the current pool.
+func (p *SelfHealingPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return p.Pool().QueryRow(ctx, sql, args...)
+}
+
+// SendBatch acts like pgxpool.Pool.SendBatch on the current pool.
+func (p *SelfHealingPool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return p.Pool().SendBatch(ctx, b)
+}
+
+// BeginTx acts like pgxpool.Pool.BeginTx on the current pool.
+func (p *SelfHealingPool) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	return p.Pool().BeginTx(ctx, txOptions)
+}
+
+func (p *SelfHealingPool) monitor(ctx context.Context) {
+	defer close(p.done)
+
+	var failures uint
+	period := p.Pool().Config().HealthCheckPeriod
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		// ping of unresponsive database must not delay the next check
+		pingCtx, cancel := context.WithTimeout(ctx, period)
+		err := p.Pool().Ping(pingCtx)
+		cancel()
+		if err == nil {
+			failures = 0
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		failures++
+		p.log.Warn("self healing pool ping failed", "failures", failures, "error", err)
+
+		if failures >= p.failureThreshold {
+			p.heal()
+			failures = 0
+			period = p.Pool().Config().HealthCheckPeriod
+			ticker.Reset(period)
+		}
+	}
+}
+
+// heal swaps the current pool with a new one and closes the old pool once its connections are released.
+func (p *SelfHealingPool) heal() {
+	pool, err := p.constructor()
+	if err != nil {
+		p.log.Error("failed to construct replacement pool", "error", err)
+		return
+	}
+
+	old := p.pool.Swap(pool)
+	p.log.Warn("replaced postgres pool after consecutive ping failures", "failure_threshold", p.failureThreshold)
+
+	p.closing.Add(1)
+	go func() {
+		defer p.closing.Done()
+		old.Close()
+	}()
+}
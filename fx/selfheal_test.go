@@ -0,0 +1,36 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx/fxtest"
+	"testing"
+	"time"
+)
+
+func TestSelfHealingPoolStopBoundedByCtx(t *testing.T) {
+	lc := fxtest.NewLifecycle(t)
+	p, err := NewSelfHealingPool(lc, func() (*pgxpool.Pool, error) {
+		return pgxpool.New(context.Background(), "postgres://user@localhost/db")
+	}, 1, NopLogger())
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	lc.RequireStart()
+
+	// replaced pool that never finishes closing
+	p.closing.Add(1)
+	defer p.closing.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err = lc.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stop took %s, want it bounded by ctx", elapsed)
+	}
+}
@@ -0,0 +1,119 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithStatementTimeout sets statement_timeout session parameter to d for every new connection, so server aborts
+// queries running longer than d even when client context has no deadline.
+func WithStatementTimeout(d time.Duration) PoolOption {
+	return withTimeoutParam("statement_timeout", d)
+}
+
+// WithLockTimeout sets lock_timeout session parameter to d for every new connection.
+func WithLockTimeout(d time.Duration) PoolOption {
+	return withTimeoutParam("lock_timeout", d)
+}
+
+// maxApplicationNameLength is NAMEDATALEN-1, the server truncates longer application_name.
+const maxApplicationNameLength = 63
+
+// WithApplicationName sets application_name startup parameter shown in pg_stat_activity, overriding the value of DSN.
+// The server replaces characters other than printable ASCII with question marks and truncates names longer than 63
+// bytes, so such names are rejected.
+func WithApplicationName(name string) PoolOption {
+	return func(o *poolOptions) error {
+		if len(name) > maxApplicationNameLength {
+			return fmt.Errorf("application_name: must not exceed %d bytes, got %d", maxApplicationNameLength, len(name))
+		}
+		for _, r := range name {
+			if r < ' ' || r > '~' {
+				return fmt.Errorf("application_name: must contain only printable ASCII characters, got %q", name)
+			}
+		}
+
+		o.config.ConnConfig.RuntimeParams["application_name"] = name
+		return nil
+	}
+}
+
+// WithSearchPath sets search_path session parameter to schemas for every new connection. Schema names must be plain
+// identifiers and are folded to lower case like unquoted names. Missing schemas do not fail connections, warning
+// about them is logged once.
+func WithSearchPath(schemas ...string) PoolOption {
+	return func(o *poolOptions) error {
+		if len(schemas) == 0 {
+			return fmt.Errorf("search_path: no schemas")
+		}
+		if err := checkIdentifiers("schema", schemas); err != nil {
+			return fmt.Errorf("search_path: %w", err)
+		}
+
+		names := make([]string, len(schemas))
+		for i, schema := range schemas {
+			names[i] = strings.ToLower(schema)
+		}
+		value := strings.Join(names, ", ")
+
+		var warnOnce sync.Once
+		o.afterConnect = append(o.afterConnect, func(ctx context.Context, conn *pgx.Conn) error {
+			if err := setSessionParam(ctx, conn, "search_path", value); err != nil {
+				return err
+			}
+
+			rows, err := conn.Query(ctx,
+				"SELECT schema_name::text FROM information_schema.schemata WHERE schema_name = ANY($1)", names)
+			if err != nil {
+				return fmt.Errorf("postgres: check search_path schemas: %w", err)
+			}
+			existing, err := pgx.CollectRows(rows, pgx.RowTo[string])
+			if err != nil {
+				return fmt.Errorf("postgres: check search_path schemas: %w", err)
+			}
+
+			var missing []string
+			for _, name := range names {
+				if !slices.Contains(existing, name) {
+					missing = append(missing, name)
+				}
+			}
+			if len(missing) > 0 {
+				warnOnce.Do(func() {
+					o.log.Warn("search_path contains missing schemas", "search_path", value, "missing", missing)
+				})
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
+func withTimeoutParam(name string, d time.Duration) PoolOption {
+	return func(o *poolOptions) error {
+		if d < 0 {
+			return fmt.Errorf("%s: must not be negative, got %s", name, d)
+		}
+
+		value := strconv.FormatInt(d.Milliseconds(), 10)
+		o.afterConnect = append(o.afterConnect, func(ctx context.Context, conn *pgx.Conn) error {
+			o.log.Debug("applying session timeout", "parameter", name, "timeout", d)
+			return setSessionParam(ctx, conn, name, value)
+		})
+		return nil
+	}
+}
+
+// setSessionParam sets session parameter name to value. Unlike SET it accepts value as query argument.
+func setSessionParam(ctx context.Context, conn *pgx.Conn, name, value string) error {
+	if _, err := conn.Exec(ctx, "SELECT set_config($1, $2, false)", name, value); err != nil {
+		return fmt.Errorf("postgres: set %s: %w", name, err)
+	}
+	return nil
+}
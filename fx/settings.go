@@ -0,0 +1,99 @@
+package fx
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithMaxConnLifetime sets the duration after which connection is closed and replaced, which limits server-side state
+// accumulated by long-lived connections. See also WithMaxConnLifetimeJitter.
+func WithMaxConnLifetime(d time.Duration) PoolOption {
+	return withPositiveDuration("max_conn_lifetime", d, func(o *poolOptions) {
+		o.config.MaxConnLifetime = d
+	})
+}
+
+// WithMaxConnLifetimeJitter adds random duration up to j to lifetime of every connection, so connections created
+// together do not expire together causing reconnect burst.
+func WithMaxConnLifetimeJitter(j time.Duration) PoolOption {
+	return withPositiveDuration("max_conn_lifetime_jitter", j, func(o *poolOptions) {
+		o.config.MaxConnLifetimeJitter = j
+	})
+}
+
+// withPositiveDuration returns option validating d and logging it after set applies it.
+func withPositiveDuration(name string, d time.Duration, set func(o *poolOptions)) PoolOption {
+	return func(o *poolOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("%s: must be positive, got %s", name, d)
+		}
+
+		set(o)
+		o.log.Info("configured pool setting", "setting", name, "value", d)
+		return nil
+	}
+}
+
+// WithMaxConnIdleTime sets the duration after which idle connection is closed by pool background checker, so
+// connections opened during traffic spike are released once it drops. Connections below pool MinConns are kept
+// regardless of idle time.
+func WithMaxConnIdleTime(d time.Duration) PoolOption {
+	return withPositiveDuration("max_conn_idle_time", d, func(o *poolOptions) {
+		o.config.MaxConnIdleTime = d
+	})
+}
+
+// WithHealthCheckPeriod sets how often pool checks idle connections. Shorter period lowers chance of serving stale
+// connection after network failure or server restart, but makes more background round trips to the database.
+func WithHealthCheckPeriod(d time.Duration) PoolOption {
+	return withPositiveDuration("health_check_period", d, func(o *poolOptions) {
+		o.config.HealthCheckPeriod = d
+	})
+}
+
+// WithConnectTimeout bounds establishing of every new connection, including TLS handshake and authentication, by d.
+// pgx waits indefinitely by default, so dial to briefly unreachable database can hang; short timeout combined with
+// WithRetry recovers faster. It is unrelated to WithAcquireTimeout and WithQueryTimeout.
+func WithConnectTimeout(d time.Duration) PoolOption {
+	return withPositiveDuration("connect_timeout", d, func(o *poolOptions) {
+		o.config.ConnConfig.ConnectTimeout = d
+	})
+}
+
+// WithMaxConns sets the maximum number of pool connections. pgxpool defaults it to the greater of 4 and number of
+// CPUs, which often exceeds database connection limit when service is scaled out. It fails if n is less than the value
+// set by WithMinConns.
+func WithMaxConns(n int32) PoolOption {
+	return func(o *poolOptions) error {
+		if n < 1 {
+			return fmt.Errorf("max_conns: must be at least 1, got %d", n)
+		}
+		if o.minConnsSet && o.config.MinConns > n {
+			return fmt.Errorf("max_conns: must not be less than min_conns %d, got %d", o.config.MinConns, n)
+		}
+
+		o.config.MaxConns = n
+		o.maxConnsSet = true
+		o.log.Info("configured pool setting", "setting", "max_conns", "value", n)
+		return nil
+	}
+}
+
+// WithMinConns sets the number of connections pool keeps open even when they are idle, which avoids latency spikes
+// after quiet periods. When database is unreachable, pool keeps trying to reopen missing connections in background.
+// It fails if n exceeds the value set by WithMaxConns.
+func WithMinConns(n int32) PoolOption {
+	return func(o *poolOptions) error {
+		if n < 0 {
+			return fmt.Errorf("min_conns: must not be negative, got %d", n)
+		}
+		if o.maxConnsSet && n > o.config.MaxConns {
+			return fmt.Errorf("min_conns: must not exceed max_conns %d, got %d", o.config.MaxConns, n)
+		}
+
+		o.config.MinConns = n
+		o.minConnsSet = true
+		o.log.Info("configured pool setting", "setting", "min_conns", "value", n)
+		return nil
+	}
+}
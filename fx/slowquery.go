@@ -0,0 +1,59 @@
+package fx
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"regexp"
+	"time"
+)
+
+var (
+	// string literal with doubled quotes as escapes, including E'' and other prefixed forms
+	sqlStringLiteralRegexp = regexp.MustCompile(`'(?:[^']|'')*'`)
+	// number not being part of identifier or $n placeholder
+	sqlNumberLiteralRegexp = regexp.MustCompile(`(^|[^\w$.])\d+(?:\.\d+)?(?:[eE][-+]?\d+)?\b`)
+)
+
+// NewSlowQueryAlerter returns pgx.QueryTracer calling fn for every query taking longer than threshold. SQL passed to
+// fn has string and number literals replaced with ?, so it is safe to report and can be used to group queries.
+func NewSlowQueryAlerter(threshold time.Duration, fn func(sql string, duration time.Duration, err error)) pgx.QueryTracer {
+	return &slowQueryAlerter{threshold: threshold, fn: fn}
+}
+
+// WithSlowQueryAlerter registers tracer returned by NewSlowQueryAlerter in addition to other tracers.
+func WithSlowQueryAlerter(threshold time.Duration, fn func(sql string, duration time.Duration, err error)) PoolOption {
+	return WithQueryTracer(NewSlowQueryAlerter(threshold, fn))
+}
+
+type slowQueryAlerter struct {
+	threshold time.Duration
+	fn        func(sql string, duration time.Duration, err error)
+}
+
+type slowQueryKey struct{}
+
+type slowQueryData struct {
+	start time.Time
+	sql   string
+}
+
+func (a *slowQueryAlerter) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, slowQueryKey{}, slowQueryData{start: time.Now(), sql: data.SQL})
+}
+
+func (a *slowQueryAlerter) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	q, ok := ctx.Value(slowQueryKey{}).(slowQueryData)
+	if !ok {
+		return
+	}
+
+	if duration := time.Since(q.start); duration > a.threshold {
+		a.fn(normalizeSQL(q.sql), duration, data.Err)
+	}
+}
+
+// normalizeSQL replaces literal values in sql with ?.
+func normalizeSQL(sql string) string {
+	sql = sqlStringLiteralRegexp.ReplaceAllString(sql, "?")
+	return sqlNumberLiteralRegexp.ReplaceAllString(sql, "${1}?")
+}
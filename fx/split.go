@@ -0,0 +1,66 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/fx"
+)
+
+// SplitPool holds pools of primary and read replica with common lifecycle.
+type SplitPool struct {
+	primary *pgxpool.Pool
+	replica *pgxpool.Pool
+}
+
+// NewSplitPool creates pools of primary and replica configured with the same opts, including AfterConnect hooks and
+// retries. Both pools are started and stopped together; their log messages are tagged with "primary" and "replica"
+// pool names.
+func NewSplitPool(lc fx.Lifecycle, primaryDSN, replicaDSN string, log Logger, opts ...PoolOption) (*SplitPool, error) {
+	primary, primaryOpts, err := createPool("primary", primaryDSN, log, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	replica, replicaOpts, err := createPool("replica", replicaDSN, log, opts)
+	if err != nil {
+		primary.Close()
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := primaryOpts.start(ctx, primary); err != nil {
+				return err
+			}
+			if err := replicaOpts.start(ctx, replica); err != nil {
+				// fx does not stop hook that failed to start
+				return errors.Join(err, primaryOpts.stop(ctx, primary))
+			}
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return errors.Join(replicaOpts.stop(ctx, replica), primaryOpts.stop(ctx, primary))
+		},
+	})
+
+	return &SplitPool{primary: primary, replica: replica}, nil
+}
+
+// Primary returns pool of primary.
+func (s *SplitPool) Primary() *pgxpool.Pool {
+	return s.primary
+}
+
+// Replica returns pool of read replica.
+func (s *SplitPool) Replica() *pgxpool.Pool {
+	return s.replica
+}
+
+// Route returns replica for read-only work and primary otherwise.
+func (s *SplitPool) Route(readOnly bool) *pgxpool.Pool {
+	if readOnly {
+		return s.replica
+	}
+	return s.primary
+}
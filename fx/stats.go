@@ -0,0 +1,105 @@
+package fx
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+// PoolStats is point-in-time copy of pgxpool.Stat suitable for JSON serialization.
+type PoolStats struct {
+	AcquireCount            int64         `json:"acquire_count"`
+	AcquireDuration         time.Duration `json:"acquire_duration_ns"`
+	AcquiredConns           int32         `json:"acquired_conns"`
+	CanceledAcquireCount    int64         `json:"canceled_acquire_count"`
+	ConstructingConns       int32         `json:"constructing_conns"`
+	EmptyAcquireCount       int64         `json:"empty_acquire_count"`
+	IdleConns               int32         `json:"idle_conns"`
+	MaxConns                int32         `json:"max_conns"`
+	TotalConns              int32         `json:"total_conns"`
+	NewConnsCount           int64         `json:"new_conns_count"`
+	MaxLifetimeDestroyCount int64         `json:"max_lifetime_destroy_count"`
+	MaxIdleDestroyCount     int64         `json:"max_idle_destroy_count"`
+}
+
+// SnapshotStats returns current statistics of pool.
+func SnapshotStats(pool *pgxpool.Pool) PoolStats {
+	stat := pool.Stat()
+
+	return PoolStats{
+		AcquireCount:            stat.AcquireCount(),
+		AcquireDuration:         stat.AcquireDuration(),
+		AcquiredConns:           stat.AcquiredConns(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		ConstructingConns:       stat.ConstructingConns(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		IdleConns:               stat.IdleConns(),
+		MaxConns:                stat.MaxConns(),
+		TotalConns:              stat.TotalConns(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+	}
+}
+
+// DeltaStats returns activity between before and after snapshots.
+//
+// Deprecated: use StatsDelta.
+func DeltaStats(before, after PoolStats) PoolStats {
+	return StatsDelta(before, after)
+}
+
+// StatsDelta returns activity between before and after snapshots: cumulative counters are subtracted and gauges, e.g.
+// AcquiredConns and IdleConns, are taken from after. Dividing counters by the time between snapshots gives rates.
+func StatsDelta(before, after PoolStats) PoolStats {
+	delta := after
+	delta.AcquireCount -= before.AcquireCount
+	delta.AcquireDuration -= before.AcquireDuration
+	delta.CanceledAcquireCount -= before.CanceledAcquireCount
+	delta.EmptyAcquireCount -= before.EmptyAcquireCount
+	delta.NewConnsCount -= before.NewConnsCount
+	delta.MaxLifetimeDestroyCount -= before.MaxLifetimeDestroyCount
+	delta.MaxIdleDestroyCount -= before.MaxIdleDestroyCount
+
+	return delta
+}
+
+// WithStatisticsLogging logs pool statistics at info level every interval while the application is running. Zero
+// interval disables logging.
+func WithStatisticsLogging(interval time.Duration) PoolOption {
+	return func(o *poolOptions) error {
+		if interval <= 0 {
+			return nil
+		}
+
+		o.background = append(o.background, func(ctx context.Context, pool *pgxpool.Pool) {
+			every(ctx, interval, func() {
+				stat := pool.Stat()
+				o.log.Info("postgres pool statistics",
+					"max_conns", stat.MaxConns(),
+					"total_conns", stat.TotalConns(),
+					"idle_conns", stat.IdleConns(),
+					"acquired_conns", stat.AcquiredConns(),
+					"new_conns_total", stat.NewConnsCount(),
+					"acquire_duration", stat.AcquireDuration(),
+				)
+			})
+		})
+		return nil
+	}
+}
+
+// every calls f every interval until ctx is done.
+func every(ctx context.Context, interval time.Duration, f func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stmtCachePrefix is prefix of names of statements prepared by pgx statement cache.
+const stmtCachePrefix = "stmtcache_"
+
+// WithStatementCacheLogging logs statistics of pgx statement cache of the pool connections every interval: queries,
+// cache hits and misses, hit rate, evictions and number of cached statements. pgx keeps the cache internal, so
+// statistics are estimated by tracing: every query counts as hit unless pgx prepares it into the cache, evictions
+// are misses exceeding cache capacity of connection. Batched queries are not counted. Nothing is logged when
+// statement cache is not used by default query exec mode.
+func WithStatementCacheLogging(interval time.Duration) PoolOption {
+	return func(o *poolOptions) error {
+		if interval <= 0 {
+			return fmt.Errorf("statement cache logging interval: must be positive, got %s", interval)
+		}
+
+		t := &stmtCacheTracer{misses: make(map[*pgx.Conn]int)}
+
+		beforeClose := o.config.BeforeClose
+		o.config.BeforeClose = func(conn *pgx.Conn) {
+			if beforeClose != nil {
+				beforeClose(conn)
+			}
+			t.forget(conn)
+		}
+
+		o.background = append(o.background, func(ctx context.Context, pool *pgxpool.Pool) {
+			cfg := pool.Config().ConnConfig
+			if cfg.DefaultQueryExecMode != pgx.QueryExecModeCacheStatement || cfg.StatementCacheCapacity <= 0 {
+				o.log.Info("postgres statement cache is disabled, statistics are not logged")
+				return
+			}
+
+			every(ctx, interval, func() {
+				stats := t.snapshot(cfg.StatementCacheCapacity)
+				hitRate := 0.0
+				if stats.queries > 0 {
+					hitRate = float64(stats.queries-stats.misses) / float64(stats.queries)
+				}
+				o.log.Info("postgres statement cache statistics",
+					"queries", stats.queries,
+					"hits", stats.queries-stats.misses,
+					"misses", stats.misses,
+					"hit_rate", hitRate,
+					"evictions", stats.evictions,
+					"cached_statements", stats.cached,
+					"capacity_per_conn", cfg.StatementCacheCapacity,
+				)
+			})
+		})
+
+		return WithQueryTracer(t)(o)
+	}
+}
+
+// stmtCacheTracer counts queries and statements prepared into statement cache.
+type stmtCacheTracer struct {
+	mu      sync.Mutex
+	queries int64
+	// misses of open connections, misses of closed ones are kept in closed
+	misses map[*pgx.Conn]int
+	closed stmtCacheStats
+}
+
+type stmtCacheStats struct {
+	queries   int64
+	misses    int64
+	evictions int64
+	cached    int64
+}
+
+func (t *stmtCacheTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	t.mu.Lock()
+	t.queries++
+	t.mu.Unlock()
+	return ctx
+}
+
+func (t *stmtCacheTracer) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+func (t *stmtCacheTracer) TracePrepareStart(
+	ctx context.Context,
+	conn *pgx.Conn,
+	data pgx.TracePrepareStartData,
+) context.Context {
+	if strings.HasPrefix(data.Name, stmtCachePrefix) {
+		t.mu.Lock()
+		t.misses[conn]++
+		t.mu.Unlock()
+	}
+	return ctx
+}
+
+func (t *stmtCacheTracer) TracePrepareEnd(context.Context, *pgx.Conn, pgx.TracePrepareEndData) {}
+
+// forget moves misses of closed conn to totals.
+func (t *stmtCacheTracer) forget(conn *pgx.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	misses, ok := t.misses[conn]
+	if !ok {
+		return
+	}
+	delete(t.misses, conn)
+
+	capacity := conn.Config().StatementCacheCapacity
+	t.closed.misses += int64(misses)
+	t.closed.evictions += int64(max(misses-capacity, 0))
+}
+
+// snapshot returns statistics since the pool was created. Only open connections count towards cached statements.
+func (t *stmtCacheTracer) snapshot(capacity int) stmtCacheStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.closed
+	stats.queries = t.queries
+	for _, misses := range t.misses {
+		stats.misses += int64(misses)
+		stats.evictions += int64(max(misses-capacity, 0))
+		stats.cached += int64(min(misses, capacity))
+	}
+	return stats
+}
@@ -0,0 +1,166 @@
+package fx
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// RetryStrategy decides whether and when failed call is retried. Next is called after every failed call with its
+// 1-based number and error.
+type RetryStrategy interface {
+	Next(attempt uint, lastErr error) (delay time.Duration, shouldRetry bool)
+}
+
+// ConstantBackoff waits the same Delay between calls.
+type ConstantBackoff struct {
+	Delay       time.Duration
+	MaxAttempts uint
+}
+
+// ConstantStrategy returns RetryStrategy making up to maxAttempts calls with delay between them.
+func ConstantStrategy(delay time.Duration, maxAttempts uint) RetryStrategy {
+	return ConstantBackoff{Delay: delay, MaxAttempts: maxAttempts}
+}
+
+// Next implements RetryStrategy.
+func (b ConstantBackoff) Next(attempt uint, _ error) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts {
+		return 0, false
+	}
+	return b.Delay, true
+}
+
+// ExponentialBackoff multiplies delay by Multiplier after every failed call. Zero MaxDelay means no cap.
+type ExponentialBackoff struct {
+	Initial     time.Duration
+	MaxDelay    time.Duration
+	Multiplier  float64
+	MaxAttempts uint
+}
+
+// ExponentialStrategy returns RetryStrategy making up to maxAttempts calls with delay starting with initial and
+// multiplied by multiplier, but never exceeding maxDelay.
+func ExponentialStrategy(initial, maxDelay time.Duration, multiplier float64, maxAttempts uint) RetryStrategy {
+	return ExponentialBackoff{Initial: initial, MaxDelay: maxDelay, Multiplier: multiplier, MaxAttempts: maxAttempts}
+}
+
+// Next implements RetryStrategy.
+func (b ExponentialBackoff) Next(attempt uint, _ error) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts {
+		return 0, false
+	}
+	return exponentialDelay(b.Initial, b.MaxDelay, b.Multiplier, attempt), true
+}
+
+// FibonacciBackoff waits Unit multiplied by Fibonacci number of failed call: 1, 1, 2, 3, 5 and so on. Zero MaxDelay
+// means no cap.
+type FibonacciBackoff struct {
+	Unit        time.Duration
+	MaxDelay    time.Duration
+	MaxAttempts uint
+}
+
+// FibonacciStrategy returns RetryStrategy making up to maxAttempts calls with delays following Fibonacci sequence.
+func FibonacciStrategy(unit time.Duration, maxAttempts uint) RetryStrategy {
+	return FibonacciBackoff{Unit: unit, MaxAttempts: maxAttempts}
+}
+
+// Next implements RetryStrategy.
+func (b FibonacciBackoff) Next(attempt uint, _ error) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts {
+		return 0, false
+	}
+
+	prev, cur := time.Duration(0), b.Unit
+	for i := uint(1); i < attempt; i++ {
+		if b.MaxDelay > 0 && cur >= b.MaxDelay {
+			break
+		}
+		if cur > math.MaxInt64-prev {
+			return capDelay(math.MaxInt64, b.MaxDelay), true
+		}
+		prev, cur = cur, prev+cur
+	}
+	return capDelay(cur, b.MaxDelay), true
+}
+
+// TryWithStrategy tries to get non-error result of calling function f until s gives up. The last error is wrapped in
+// *RetryExhaustedError then.
+func TryWithStrategy(f func() error, s RetryStrategy) error {
+	_, err := tryWithStrategy(context.Background(), f, s, retryLoop{})
+	return err
+}
+
+// TryWithStrategyCtx is context aware version of TryWithStrategy. It stops waiting for the next attempt as soon as ctx
+// is done and returns ctx.Err().
+func TryWithStrategyCtx(ctx context.Context, f func(context.Context) error, s RetryStrategy) error {
+	_, err := tryWithStrategy(ctx, func() error {
+		return f(ctx)
+	}, s, retryLoop{})
+	return err
+}
+
+// retryLoop holds settings applied to retries regardless of RetryStrategy.
+type retryLoop struct {
+	maxDuration time.Duration
+	logger      Logger
+	observer    func(attempt uint, err error, delay time.Duration)
+	onFinish    func(attempts uint, err error, duration time.Duration)
+}
+
+func tryWithStrategy(ctx context.Context, f func() error, s RetryStrategy, loop retryLoop) (attempts uint, err error) {
+	start := time.Now()
+	if loop.onFinish != nil {
+		defer func() {
+			loop.onFinish(attempts, err, time.Since(start))
+		}()
+	}
+
+	for i := uint(1); ; i++ {
+		if err = f(); err == nil {
+			return i, nil
+		}
+
+		delay, ok := s.Next(i, err)
+		if !ok || loop.maxDuration > 0 && time.Since(start) >= loop.maxDuration {
+			return i, &RetryExhaustedError{Attempts: i, TotalDuration: time.Since(start), Err: err}
+		}
+		if loop.maxDuration > 0 {
+			delay = min(delay, loop.maxDuration-time.Since(start))
+		}
+
+		if loop.logger != nil {
+			loop.logger.Warn("got error in attempter", "attempts", i, "error", err)
+		}
+		if loop.observer != nil {
+			loop.observer(i, err, delay)
+		}
+		if err = sleepCtx(ctx, delay); err != nil {
+			return i, err
+		}
+	}
+}
+
+// exponentialDelay returns delay after attempt failed calls, starting with initial and multiplied by multiplier.
+// Multiplier below 1 is treated as 1.
+func exponentialDelay(initial, maxDelay time.Duration, multiplier float64, attempt uint) time.Duration {
+	delay := initial
+	if multiplier <= 1 {
+		return capDelay(delay, maxDelay)
+	}
+
+	for i := uint(1); i < attempt; i++ {
+		next := float64(delay) * multiplier
+		if next >= math.MaxInt64 {
+			delay = math.MaxInt64
+		} else {
+			delay = time.Duration(next)
+		}
+		delay = capDelay(delay, maxDelay)
+		if maxDelay > 0 && delay == maxDelay {
+			break
+		}
+	}
+	return capDelay(delay, maxDelay)
+}
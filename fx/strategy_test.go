@@ -0,0 +1,220 @@
+package fx
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffNext(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff ExponentialBackoff
+		want    []time.Duration
+	}{
+		{
+			name:    "doubles delay",
+			backoff: ExponentialBackoff{Initial: time.Second, Multiplier: 2, MaxAttempts: 6},
+			want:    []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second},
+		},
+		{
+			name:    "caps delay at max delay",
+			backoff: ExponentialBackoff{Initial: time.Second, MaxDelay: 5 * time.Second, Multiplier: 2, MaxAttempts: 6},
+			want:    []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:    "initial delay above max delay",
+			backoff: ExponentialBackoff{Initial: 10 * time.Second, MaxDelay: 3 * time.Second, Multiplier: 2, MaxAttempts: 3},
+			want:    []time.Duration{3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:    "multiplier one keeps delay constant",
+			backoff: ExponentialBackoff{Initial: time.Second, Multiplier: 1, MaxAttempts: 4},
+			want:    []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name:    "multiplier below one is treated as one",
+			backoff: ExponentialBackoff{Initial: time.Second, Multiplier: 0.5, MaxAttempts: 3},
+			want:    []time.Duration{time.Second, time.Second},
+		},
+		{
+			name:    "fractional multiplier",
+			backoff: ExponentialBackoff{Initial: time.Second, Multiplier: 1.5, MaxAttempts: 4},
+			want:    []time.Duration{time.Second, 1500 * time.Millisecond, 2250 * time.Millisecond},
+		},
+		{
+			name:    "huge delay does not overflow",
+			backoff: ExponentialBackoff{Initial: time.Hour, Multiplier: 1000, MaxAttempts: 10},
+			want: []time.Duration{
+				time.Hour, 1000 * time.Hour, 1000000 * time.Hour,
+				1<<63 - 1, 1<<63 - 1, 1<<63 - 1, 1<<63 - 1, 1<<63 - 1, 1<<63 - 1,
+			},
+		},
+		{
+			name:    "single attempt",
+			backoff: ExponentialBackoff{Initial: time.Second, Multiplier: 2, MaxAttempts: 1},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := delays(tt.backoff); !slices.Equal(got, tt.want) {
+				t.Errorf("delays = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstantBackoffNext(t *testing.T) {
+	got := delays(ConstantBackoff{Delay: time.Second, MaxAttempts: 4})
+	want := []time.Duration{time.Second, time.Second, time.Second}
+	if !slices.Equal(got, want) {
+		t.Errorf("delays = %v, want %v", got, want)
+	}
+}
+
+func TestFibonacciBackoffNext(t *testing.T) {
+	ms := time.Millisecond
+
+	tests := []struct {
+		name    string
+		backoff RetryStrategy
+		want    []time.Duration
+	}{
+		{
+			name:    "first ten delays",
+			backoff: FibonacciStrategy(ms, 11),
+			want:    []time.Duration{1 * ms, 1 * ms, 2 * ms, 3 * ms, 5 * ms, 8 * ms, 13 * ms, 21 * ms, 34 * ms, 55 * ms},
+		},
+		{
+			name:    "caps delay at max delay",
+			backoff: FibonacciBackoff{Unit: ms, MaxDelay: 10 * ms, MaxAttempts: 9},
+			want:    []time.Duration{1 * ms, 1 * ms, 2 * ms, 3 * ms, 5 * ms, 8 * ms, 10 * ms, 10 * ms},
+		},
+		{
+			name:    "huge delay does not overflow",
+			backoff: FibonacciBackoff{Unit: 1 << 61, MaxAttempts: 6},
+			want:    []time.Duration{1 << 61, 1 << 61, 1 << 62, 3 << 61, 1<<63 - 1},
+		},
+		{
+			name:    "single attempt",
+			backoff: FibonacciStrategy(ms, 1),
+			want:    nil,
+		},
+		{
+			name:    "zero attempts",
+			backoff: FibonacciStrategy(ms, 0),
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := delays(tt.backoff); !slices.Equal(got, tt.want) {
+				t.Errorf("delays = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTryWithStrategySchedule(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name      string
+		opts      RetryOptions
+		failures  int
+		wantCalls int
+		wantErr   bool
+		want      []time.Duration
+	}{
+		{
+			name:      "exponential until success",
+			opts:      RetryOptions{Attempts: 5, InitialDelay: time.Millisecond, Multiplier: 2},
+			failures:  3,
+			wantCalls: 4,
+			want:      []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond},
+		},
+		{
+			name: "exponential capped by max delay until exhausted",
+			opts: RetryOptions{
+				Attempts: 5, InitialDelay: time.Millisecond, MaxDelay: 3 * time.Millisecond, Multiplier: 2,
+			},
+			failures:  5,
+			wantCalls: 5,
+			wantErr:   true,
+			want:      []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 3 * time.Millisecond},
+		},
+		{
+			name:      "no retry on first success",
+			opts:      RetryOptions{Attempts: 5, InitialDelay: time.Millisecond, Multiplier: 2},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []time.Duration
+			tt.opts.Observer = func(_ uint, _ error, delay time.Duration) {
+				got = append(got, delay)
+			}
+
+			calls := 0
+			err := TryWithOptions(func() error {
+				calls++
+				if calls <= tt.failures {
+					return errFailed
+				}
+				return nil
+			}, tt.opts)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errFailed) {
+				t.Errorf("err = %v, want wrapped %v", err, errFailed)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("delays = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTryWithAttemptsBackoff(t *testing.T) {
+	calls := 0
+	start := time.Now()
+	err := TryWithAttemptsBackoff(func() error {
+		calls++
+		return errors.New("failed")
+	}, 4, time.Millisecond, 2*time.Millisecond, 2)
+
+	var exhausted *RetryExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("err = %v, want *RetryExhaustedError", err)
+	}
+	if exhausted.Attempts != 4 || calls != 4 {
+		t.Errorf("attempts = %d, calls = %d, want 4", exhausted.Attempts, calls)
+	}
+	// 1ms + 2ms + 2ms (capped)
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Errorf("elapsed = %s, want at least 5ms", elapsed)
+	}
+}
+
+// delays returns every delay s makes before giving up.
+func delays(s RetryStrategy) []time.Duration {
+	var got []time.Duration
+	for attempt := uint(1); ; attempt++ {
+		delay, ok := s.Next(attempt, nil)
+		if !ok {
+			return got
+		}
+		got = append(got, delay)
+	}
+}
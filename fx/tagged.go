@@ -0,0 +1,212 @@
+package fx
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTagReuseWindow is the default time cached connection stays reusable for its tag.
+	DefaultTagReuseWindow = 30 * time.Second
+	// DefaultTagCacheSize is the default number of connections cached by TaggedPool.
+	DefaultTagCacheSize = 4
+)
+
+// TaggedPoolOption configures TaggedPool.
+type TaggedPoolOption func(p *TaggedPool)
+
+// WithReuseWindow sets how long released connection stays cached for its tag.
+func WithReuseWindow(d time.Duration) TaggedPoolOption {
+	return func(p *TaggedPool) {
+		p.reuseWindow = d
+	}
+}
+
+// WithTagCacheSize sets the maximum number of cached connections. Cached connections are not returned to the pool, so
+// n must be well below MaxConns of the pool.
+func WithTagCacheSize(n int) TaggedPoolOption {
+	return func(p *TaggedPool) {
+		p.size = n
+	}
+}
+
+// TaggedPool caches released connections by tag, e.g. tenant id, so session state set up for the tag is reused by the
+// next acquire with the same tag.
+type TaggedPool struct {
+	pool        *pgxpool.Pool
+	setup       func(ctx context.Context, conn *pgxpool.Conn, tag string) error
+	reuseWindow time.Duration
+	size        int
+
+	mu sync.Mutex
+	// cached connections, the most recently released first
+	cache *list.List
+}
+
+type taggedEntry struct {
+	conn       *pgxpool.Conn
+	tag        string
+	releasedAt time.Time
+}
+
+// TaggedConn is connection acquired by TaggedPool.AcquireTagged. It must be released with Release.
+type TaggedConn struct {
+	*pgxpool.Conn
+	tag  string
+	pool *TaggedPool
+}
+
+// NewTaggedPool returns TaggedPool using setup to prepare session of connection for a tag, e.g. by setting
+// app.tenant_id parameter.
+func NewTaggedPool(
+	pool *pgxpool.Pool,
+	setup func(ctx context.Context, conn *pgxpool.Conn, tag string) error,
+	opts ...TaggedPoolOption,
+) *TaggedPool {
+	p := &TaggedPool{
+		pool:        pool,
+		setup:       setup,
+		reuseWindow: DefaultTagReuseWindow,
+		size:        DefaultTagCacheSize,
+		cache:       list.New(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// AcquireTagged returns cached connection set up for tag if it was released within reuse window. Otherwise it acquires
+// connection from the pool and sets it up for tag.
+func (p *TaggedPool) AcquireTagged(ctx context.Context, tag string) (*TaggedConn, error) {
+	if conn := p.take(tag); conn != nil {
+		return &TaggedConn{Conn: conn, tag: tag, pool: p}, nil
+	}
+
+	if stat := p.pool.Stat(); stat.AcquiredConns() >= stat.MaxConns() {
+		// cached connections must not starve the pool
+		p.evictOldest()
+	}
+
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.setup(ctx, conn, tag); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("postgres: set up connection for tag %s: %w", tag, err)
+	}
+
+	return &TaggedConn{Conn: conn, tag: tag, pool: p}, nil
+}
+
+// InvalidateTag returns all connections cached for tag to the pool.
+func (p *TaggedPool) InvalidateTag(tag string) {
+	p.mu.Lock()
+	var conns []*pgxpool.Conn
+	for e := p.cache.Front(); e != nil; {
+		next := e.Next()
+		if entry := e.Value.(taggedEntry); entry.tag == tag {
+			conns = append(conns, entry.conn)
+			p.cache.Remove(e)
+		}
+		e = next
+	}
+	p.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Release()
+	}
+}
+
+// Close returns all cached connections to the pool. It must be called before the pool is closed.
+func (p *TaggedPool) Close() {
+	p.mu.Lock()
+	var conns []*pgxpool.Conn
+	for e := p.cache.Front(); e != nil; e = e.Next() {
+		conns = append(conns, e.Value.(taggedEntry).conn)
+	}
+	p.cache.Init()
+	p.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Release()
+	}
+}
+
+// Release caches connection for its tag. Closed connection or connection left inside transaction is returned to the
+// pool instead.
+func (c *TaggedConn) Release() {
+	if c.Conn == nil {
+		return
+	}
+
+	conn := c.Conn
+	c.Conn = nil
+	if conn.Conn().IsClosed() || conn.Conn().PgConn().TxStatus() != 'I' {
+		conn.Release()
+		return
+	}
+
+	c.pool.put(conn, c.tag)
+}
+
+// take removes the most recently released connection of tag from cache. Expired connections are returned to the pool.
+func (p *TaggedPool) take(tag string) *pgxpool.Conn {
+	p.mu.Lock()
+	var (
+		found   *pgxpool.Conn
+		expired []*pgxpool.Conn
+	)
+	for e := p.cache.Front(); e != nil; {
+		next := e.Next()
+		entry := e.Value.(taggedEntry)
+		switch {
+		case time.Since(entry.releasedAt) > p.reuseWindow:
+			expired = append(expired, entry.conn)
+			p.cache.Remove(e)
+		case found == nil && entry.tag == tag:
+			found = entry.conn
+			p.cache.Remove(e)
+		}
+		e = next
+	}
+	p.mu.Unlock()
+
+	for _, conn := range expired {
+		conn.Release()
+	}
+	return found
+}
+
+func (p *TaggedPool) put(conn *pgxpool.Conn, tag string) {
+	p.mu.Lock()
+	p.cache.PushFront(taggedEntry{conn: conn, tag: tag, releasedAt: time.Now()})
+
+	var evicted []*pgxpool.Conn
+	for p.cache.Len() > max(p.size, 0) {
+		evicted = append(evicted, p.cache.Remove(p.cache.Back()).(taggedEntry).conn)
+	}
+	p.mu.Unlock()
+
+	for _, conn := range evicted {
+		conn.Release()
+	}
+}
+
+func (p *TaggedPool) evictOldest() {
+	p.mu.Lock()
+	back := p.cache.Back()
+	if back == nil {
+		p.mu.Unlock()
+		return
+	}
+	conn := p.cache.Remove(back).(taggedEntry).conn
+	p.mu.Unlock()
+
+	conn.Release()
+}
@@ -0,0 +1,108 @@
+package testhelpers
+
+import (
+	"context"
+	"errors"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/jackc/pgx/v5/pgconn"
+	"net"
+	"sync"
+)
+
+// ErrInjectedFault is the default error of dials failed by FaultInjector.
+var ErrInjectedFault = errors.New("testhelpers: connection broken by fault injector")
+
+// FaultInjector is dial function that can be switched to failing, simulating database going away and coming back.
+type FaultInjector struct {
+	dial pgconn.DialFunc
+
+	mu     sync.Mutex
+	err    error
+	broken bool
+	conns  map[*faultConn]struct{}
+}
+
+// NewFaultInjector returns FaultInjector dialing with realDialFn while it is not broken. Nil realDialFn means
+// net.Dialer.
+func NewFaultInjector(realDialFn pgconn.DialFunc) *FaultInjector {
+	if realDialFn == nil {
+		var dialer net.Dialer
+		realDialFn = dialer.DialContext
+	}
+	return &FaultInjector{dial: realDialFn, err: ErrInjectedFault, conns: make(map[*faultConn]struct{})}
+}
+
+// WithFaultInjector makes the pool dial connections with fi.
+func WithFaultInjector(fi *FaultInjector) pgxfx.PoolOption {
+	return pgxfx.WithDialFunc(fi.Dial)
+}
+
+// SetError sets error returned by dials while fi is broken. Nil err means ErrInjectedFault.
+func (fi *FaultInjector) SetError(err error) {
+	if err == nil {
+		err = ErrInjectedFault
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fi.err = err
+}
+
+// BreakConnections closes connections dialed by fi and makes subsequent dials fail until Restore is called.
+func (fi *FaultInjector) BreakConnections() {
+	fi.mu.Lock()
+	fi.broken = true
+	conns := fi.conns
+	fi.conns = make(map[*faultConn]struct{})
+	fi.mu.Unlock()
+
+	for c := range conns {
+		_ = c.Conn.Close()
+	}
+}
+
+// Restore makes dials succeed again.
+func (fi *FaultInjector) Restore() {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fi.broken = false
+}
+
+// Dial implements pgconn.DialFunc.
+func (fi *FaultInjector) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	fi.mu.Lock()
+	broken, err := fi.broken, fi.err
+	fi.mu.Unlock()
+	if broken {
+		return nil, err
+	}
+
+	conn, err := fi.dial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &faultConn{Conn: conn, fi: fi}
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if fi.broken {
+		// broken while dialing
+		_ = conn.Close()
+		return nil, fi.err
+	}
+	fi.conns[c] = struct{}{}
+	return c, nil
+}
+
+// faultConn is connection dialed by FaultInjector, forgotten by it when closed.
+type faultConn struct {
+	net.Conn
+	fi *FaultInjector
+}
+
+func (c *faultConn) Close() error {
+	c.fi.mu.Lock()
+	delete(c.fi.conns, c)
+	c.fi.mu.Unlock()
+	return c.Conn.Close()
+}
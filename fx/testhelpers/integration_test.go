@@ -0,0 +1,282 @@
+package testhelpers_test
+
+import (
+	"context"
+	"errors"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/grbisba/package/pgxpool/fx/testhelpers"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx/fxtest"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// newIntegrationPool is testhelpers.NewTestPool skipping the test in short mode or when docker is not available.
+func newIntegrationPool(t *testing.T, opts ...testhelpers.TestOption) *pgxpool.Pool {
+	t.Helper()
+	skipIntegration(t)
+	return testhelpers.NewTestPool(t, opts...)
+}
+
+func skipIntegration(t *testing.T) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("integration test skipped in short mode")
+	}
+
+	// testcontainers panics instead of skipping when docker host can not be found at all
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("docker is not available: %v", r)
+		}
+	}()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+}
+
+func TestAcquireTimeoutIntegration(t *testing.T) {
+	pool := newIntegrationPool(t, testhelpers.WithPoolOptions(
+		pgxfx.WithMaxConns(2),
+		pgxfx.WithAcquireTimeout(200*time.Millisecond),
+	))
+	ctx := context.Background()
+
+	// hold every connection of the pool from concurrent goroutines
+	var (
+		held    sync.WaitGroup
+		release = make(chan struct{})
+		done    sync.WaitGroup
+	)
+	for range 2 {
+		held.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			conn, err := pool.Acquire(ctx)
+			held.Done()
+			if err != nil {
+				t.Errorf("acquire connection to hold: %v", err)
+				return
+			}
+			<-release
+			conn.Release()
+		}()
+	}
+	held.Wait()
+
+	start := time.Now()
+	_, err := pool.Exec(ctx, "SELECT 1")
+	elapsed := time.Since(start)
+	close(release)
+	done.Wait()
+
+	if !pgxfx.IsAcquireTimeoutError(err) {
+		t.Fatalf("err = %v, want acquire timeout", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want it to match %v", err, context.DeadlineExceeded)
+	}
+	if elapsed < 200*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("waited %s for connection, want about 200ms", elapsed)
+	}
+
+	// connections are free again, so the timeout does not affect queries
+	if _, err = pool.Exec(ctx, "SELECT pg_sleep(0.5)"); err != nil {
+		t.Errorf("query longer than acquire timeout: %v", err)
+	}
+}
+
+func TestQueryEachIntegration(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+
+	type row struct {
+		N int64
+	}
+
+	errStop := errors.New("stop")
+	var seen []int64
+	err := pgxfx.QueryEach(ctx, pool, "SELECT n FROM generate_series(1, 1000000) AS n", nil, func(r row) error {
+		seen = append(seen, r.N)
+		if r.N == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("err = %v, want %v", err, errStop)
+	}
+	if len(seen) != 3 {
+		t.Errorf("seen %d rows, want 3", len(seen))
+	}
+
+	// rows of stopped iteration are closed, so the connection is back in the pool
+	if stat := pool.Stat(); stat.AcquiredConns() != 0 {
+		t.Errorf("acquired connections = %d, want 0", stat.AcquiredConns())
+	}
+
+	_, err = pgxfx.QueryAll[row](ctx, pool, "SELECT n FROM missing_table")
+	if pgErr := (*pgconn.PgError)(nil); !errors.As(err, &pgErr) || pgErr.Code != "42P01" {
+		t.Errorf("err = %v, want undefined table error", err)
+	}
+}
+
+func TestBatchBuilderIntegration(t *testing.T) {
+	pool := newIntegrationPool(t, testhelpers.WithInitSQL(
+		"CREATE TABLE batch_items (id int PRIMARY KEY)",
+	))
+	ctx := context.Background()
+
+	br, err := pgxfx.NewBatchBuilder().
+		Add("INSERT INTO batch_items (id) VALUES ($1)", 1).
+		Add("INSERT INTO batch_items (id) VALUES ($1)", 1).
+		Add("SELECT count(*) FROM batch_items").
+		Execute(ctx, pool)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if !br.Next() || br.QueryError() != nil {
+		t.Fatalf("first insert failed: %v", br.QueryError())
+	}
+	if !br.Next() || !pgxfx.IsUniqueViolation(br.QueryError()) {
+		t.Fatalf("duplicate insert err = %v, want unique violation", br.QueryError())
+	}
+	// statements after the failed one are not executed, as implicit transaction of the batch is aborted
+	var count int64
+	if br.Next() && br.Scan(&count) == nil {
+		t.Errorf("statement after failed one returned count %d", count)
+	}
+
+	if err = br.Close(); err == nil {
+		t.Error("Close of failed batch returned nil")
+	}
+	if stat := pool.Stat(); stat.AcquiredConns() != 0 {
+		t.Errorf("acquired connections after Close = %d, want 0", stat.AcquiredConns())
+	}
+
+	if err = pool.QueryRow(ctx, "SELECT count(*) FROM batch_items").Scan(&count); err != nil || count != 0 {
+		t.Errorf("rows after failed batch = %d, err %v, want 0", count, err)
+	}
+}
+
+func TestReadOnlyPoolIntegration(t *testing.T) {
+	skipIntegration(t)
+	dsn := testhelpers.StartPostgres(t)
+	ctx := context.Background()
+
+	lc := fxtest.NewLifecycle(t)
+	writer, err := pgxfx.New(lc, dsn, pgxfx.NopLogger())
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	readOnly, err := pgxfx.NewReadOnlyPool(lc, dsn, pgxfx.NopLogger())
+	if err != nil {
+		t.Fatalf("create read-only pool: %v", err)
+	}
+	lc.RequireStart()
+	t.Cleanup(lc.RequireStop)
+
+	if _, err = writer.Exec(ctx, "CREATE TABLE readonly_items (id int)"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	_, err = readOnly.Exec(ctx, "INSERT INTO readonly_items (id) VALUES (1)")
+	if pgErr := (*pgconn.PgError)(nil); !errors.As(err, &pgErr) || pgErr.Code != "25006" {
+		t.Errorf("insert on read-only pool err = %v, want SQLSTATE 25006", err)
+	}
+
+	var count int
+	if err = readOnly.QueryRow(ctx, "SELECT count(*) FROM readonly_items").Scan(&count); err != nil {
+		t.Errorf("select on read-only pool: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("rows = %d, want 0", count)
+	}
+}
+
+func TestMigrationsRunBeforeOnStartIntegration(t *testing.T) {
+	skipIntegration(t)
+	dsn := testhelpers.StartPostgres(t)
+
+	migrations := fstest.MapFS{
+		"00001_items.sql": {Data: []byte("-- +goose Up\nCREATE TABLE migrated_items (id int);\n")},
+	}
+
+	var count int
+	lc := fxtest.NewLifecycle(t)
+	_, err := pgxfx.New(lc, dsn, pgxfx.NopLogger(),
+		// hook is given first, but migrations still run before it
+		pgxfx.WithOnStart(func(ctx context.Context, pool *pgxpool.Pool) error {
+			return pool.QueryRow(ctx, "SELECT count(*) FROM migrated_items").Scan(&count)
+		}),
+		pgxfx.WithMigrations(".", pgxfx.WithEmbeddedMigrations(migrations)),
+	)
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+
+	if err = lc.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	t.Cleanup(lc.RequireStop)
+}
+
+func TestRangeTypesIntegration(t *testing.T) {
+	tests := []struct {
+		version        string
+		wantMultirange bool
+	}{
+		{version: "13-alpine", wantMultirange: false},
+		{version: "16-alpine", wantMultirange: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			skipIntegration(t)
+			dsn := testhelpers.StartPostgres(t, testhelpers.WithPostgresVersion(tt.version))
+			ctx := context.Background()
+
+			setup, err := pgx.Connect(ctx, dsn)
+			if err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			defer setup.Close(ctx)
+			if _, err = setup.Exec(ctx, "CREATE TYPE floatrange AS RANGE (subtype = float8)"); err != nil {
+				t.Fatalf("create range type: %v", err)
+			}
+
+			lc := fxtest.NewLifecycle(t)
+			pool, err := pgxfx.New(lc, dsn, pgxfx.NopLogger(), pgxfx.WithRangeTypes("floatrange"))
+			if err != nil {
+				t.Fatalf("create pool: %v", err)
+			}
+			lc.RequireStart()
+			t.Cleanup(lc.RequireStop)
+
+			conn, err := pool.Acquire(ctx)
+			if err != nil {
+				t.Fatalf("acquire connection with range types: %v", err)
+			}
+			defer conn.Release()
+
+			var r pgtype.Range[float64]
+			if err = conn.QueryRow(ctx, "SELECT '[1.5,2.5)'::floatrange").Scan(&r); err != nil {
+				t.Fatalf("scan range: %v", err)
+			}
+			if r.Lower != 1.5 || r.Upper != 2.5 {
+				t.Errorf("range = [%v, %v), want [1.5, 2.5)", r.Lower, r.Upper)
+			}
+
+			_, ok := conn.Conn().TypeMap().TypeForName("floatmultirange")
+			if ok != tt.wantMultirange {
+				t.Errorf("multirange registered = %t, want %t", ok, tt.wantMultirange)
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package testhelpers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+)
+
+var _ pgxfx.Querier = (*MockQuerier)(nil)
+
+// ErrUnexpectedCall is returned by MockQuerier for statement not matching the next expectation.
+var ErrUnexpectedCall = errors.New("testhelpers: unexpected call")
+
+// Call is a statement received by MockQuerier. Transaction control is recorded as BEGIN, COMMIT and ROLLBACK.
+type Call struct {
+	SQL  string
+	Args []any
+}
+
+// MockQuerier is pgxfx.Querier answering statements with responses configured by Expect. Expectations are matched in
+// order they were added and every expectation answers a single call. Call not matching the next expectation fails the
+// test with t.Errorf, so the mock can be called from any goroutine, and gets ErrUnexpectedCall.
+type MockQuerier struct {
+	t testing.TB
+
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+}
+
+// NewMockQuerier returns MockQuerier failing t on unexpected calls. Expectations not met by the end of the test fail
+// it as well.
+func NewMockQuerier(t testing.TB) *MockQuerier {
+	m := &MockQuerier{t: t}
+	t.Cleanup(func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		for _, e := range m.expectations {
+			t.Errorf("testhelpers: expected statement was not called: %s", e.sql)
+		}
+	})
+	return m
+}
+
+// Expectation is response to statement configured by MockQuerier.Expect.
+type Expectation struct {
+	sql      string
+	args     []any
+	anyArgs  bool
+	columns  []string
+	rows     [][]any
+	tag      pgconn.CommandTag
+	err      error
+	rowsRead bool
+}
+
+// Expect adds expectation of statement sql. By default it matches any args and returns no rows.
+func (m *MockQuerier) Expect(sql string) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &Expectation{sql: sql, anyArgs: true}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// WithArgs makes e match only calls with args deeply equal to given ones.
+func (e *Expectation) WithArgs(args ...any) *Expectation {
+	e.args = args
+	e.anyArgs = false
+	return e
+}
+
+// WithColumns sets column names of returned rows, which are needed to scan rows by name.
+func (e *Expectation) WithColumns(columns ...string) *Expectation {
+	e.columns = columns
+	return e
+}
+
+// WithCommandTag sets command tag returned by Exec, e.g. "INSERT 0 1".
+func (e *Expectation) WithCommandTag(tag string) *Expectation {
+	e.tag = pgconn.NewCommandTag(tag)
+	return e
+}
+
+// Return sets rows returned by Query and QueryRow and error returned by every method.
+func (e *Expectation) Return(rows [][]any, err error) *Expectation {
+	e.rows = rows
+	e.err = err
+	return e
+}
+
+// Calls returns all statements received so far.
+func (m *MockQuerier) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.calls)
+}
+
+// Exec implements pgxfx.Querier.
+func (m *MockQuerier) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	e := m.match(sql, args)
+	return e.tag, e.err
+}
+
+// Query implements pgxfx.Querier.
+func (m *MockQuerier) Query(_ context.Context, sql string, args ...any) (pgx.Rows, error) {
+	e := m.match(sql, args)
+	if e.err != nil {
+		return nil, e.err
+	}
+	return newMockRows(e), nil
+}
+
+// QueryRow implements pgxfx.Querier.
+func (m *MockQuerier) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	e := m.match(sql, args)
+	if e.err != nil {
+		return &mockRows{err: e.err}
+	}
+	return &mockRow{rows: newMockRows(e)}
+}
+
+// SendBatch implements pgxfx.Querier. Every queued statement is matched with expectations when its result is read.
+func (m *MockQuerier) SendBatch(_ context.Context, b *pgx.Batch) pgx.BatchResults {
+	return &mockBatchResults{m: m, queries: b.QueuedQueries}
+}
+
+// BeginTx implements pgxfx.Querier. Statements of returned transaction are matched with expectations of m.
+func (m *MockQuerier) BeginTx(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+	m.record("BEGIN", nil)
+	return &mockTx{m: m}, nil
+}
+
+func (m *MockQuerier) record(sql string, args []any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{SQL: sql, Args: args})
+}
+
+// match records call and returns the next expectation. If it does not match, the test is marked failed and
+// expectation returning ErrUnexpectedCall is returned instead; the next expectation is kept for the following calls.
+func (m *MockQuerier) match(sql string, args []any) *Expectation {
+	m.t.Helper()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{SQL: sql, Args: args})
+
+	var err error
+	switch {
+	case len(m.expectations) == 0:
+		err = fmt.Errorf("%w: statement %s with args %v", ErrUnexpectedCall, sql, args)
+	case m.expectations[0].sql != sql:
+		err = fmt.Errorf("%w: statement %s, expected %s", ErrUnexpectedCall, sql, m.expectations[0].sql)
+	case !m.expectations[0].matchArgs(args):
+		err = fmt.Errorf("%w: args %v of statement %s, expected %v", ErrUnexpectedCall, args, sql, m.expectations[0].args)
+	}
+	if err != nil {
+		m.t.Errorf("%v", err)
+		return &Expectation{sql: sql, err: err}
+	}
+
+	e := m.expectations[0]
+	m.expectations = m.expectations[1:]
+	return e
+}
+
+func (e *Expectation) matchArgs(args []any) bool {
+	return e.anyArgs || reflect.DeepEqual(e.args, args) || len(e.args) == 0 && len(args) == 0
+}
+
+type mockRows struct {
+	columns []string
+	rows    [][]any
+	tag     pgconn.CommandTag
+	pos     int
+	err     error
+	closed  bool
+}
+
+func newMockRows(e *Expectation) *mockRows {
+	return &mockRows{columns: e.columns, rows: e.rows, tag: e.tag}
+}
+
+func (r *mockRows) Close() {
+	r.closed = true
+}
+
+func (r *mockRows) Err() error {
+	return r.err
+}
+
+func (r *mockRows) CommandTag() pgconn.CommandTag {
+	return r.tag
+}
+
+func (r *mockRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(r.columns))
+	for i, name := range r.columns {
+		fields[i] = pgconn.FieldDescription{Name: name}
+	}
+	return fields
+}
+
+func (r *mockRows) Next() bool {
+	if r.closed || r.err != nil || r.pos >= len(r.rows) {
+		r.closed = true
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *mockRows) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.pos == 0 || r.pos > len(r.rows) {
+		return errors.New("testhelpers: Scan called without successful Next")
+	}
+
+	row := r.rows[r.pos-1]
+	if len(dest) != len(row) {
+		return fmt.Errorf("testhelpers: row has %d values, got %d destinations", len(row), len(dest))
+	}
+	for i, d := range dest {
+		if err := assign(d, row[i]); err != nil {
+			return fmt.Errorf("testhelpers: scan value %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (r *mockRows) Values() ([]any, error) {
+	if r.pos == 0 || r.pos > len(r.rows) {
+		return nil, errors.New("testhelpers: Values called without successful Next")
+	}
+	return slices.Clone(r.rows[r.pos-1]), nil
+}
+
+func (r *mockRows) RawValues() [][]byte {
+	return nil
+}
+
+func (r *mockRows) Conn() *pgx.Conn {
+	return nil
+}
+
+// mockRow is pgx.Row reading the first row of rows.
+type mockRow struct {
+	rows *mockRows
+}
+
+func (r *mockRow) Scan(dest ...any) error {
+	defer r.rows.Close()
+
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return pgx.ErrNoRows
+	}
+	return r.rows.Scan(dest...)
+}
+
+// assign stores value into dest pointer, converting it if needed.
+func assign(dest, value any) error {
+	if s, ok := dest.(sql.Scanner); ok {
+		return s.Scan(value)
+	}
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return fmt.Errorf("destination %T is not a pointer", dest)
+	}
+	dv = dv.Elem()
+
+	if value == nil {
+		dv.SetZero()
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(dv.Type()):
+		dv.Set(v)
+	case dv.Kind() == reflect.Pointer && v.Type().AssignableTo(dv.Type().Elem()):
+		p := reflect.New(dv.Type().Elem())
+		p.Elem().Set(v)
+		dv.Set(p)
+	case v.Type().ConvertibleTo(dv.Type()):
+		dv.Set(v.Convert(dv.Type()))
+	default:
+		return fmt.Errorf("can not assign %T to %s", value, dv.Type())
+	}
+	return nil
+}
+
+type mockBatchResults struct {
+	m       *MockQuerier
+	queries []*pgx.QueuedQuery
+	pos     int
+}
+
+func (r *mockBatchResults) next() (*pgx.QueuedQuery, error) {
+	if r.pos >= len(r.queries) {
+		return nil, errors.New("testhelpers: no more batch results")
+	}
+	q := r.queries[r.pos]
+	r.pos++
+	return q, nil
+}
+
+func (r *mockBatchResults) Exec() (pgconn.CommandTag, error) {
+	q, err := r.next()
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return r.m.Exec(context.Background(), q.SQL, q.Arguments...)
+}
+
+func (r *mockBatchResults) Query() (pgx.Rows, error) {
+	q, err := r.next()
+	if err != nil {
+		return nil, err
+	}
+	return r.m.Query(context.Background(), q.SQL, q.Arguments...)
+}
+
+func (r *mockBatchResults) QueryRow() pgx.Row {
+	q, err := r.next()
+	if err != nil {
+		return &mockRows{err: err}
+	}
+	return r.m.QueryRow(context.Background(), q.SQL, q.Arguments...)
+}
+
+// Close consumes results that were not read, so their statements are matched with expectations too.
+func (r *mockBatchResults) Close() error {
+	for r.pos < len(r.queries) {
+		if _, err := r.Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mockTx is pgx.Tx running statements on MockQuerier.
+type mockTx struct {
+	m *MockQuerier
+}
+
+func (tx *mockTx) Begin(context.Context) (pgx.Tx, error) {
+	tx.m.record("SAVEPOINT", nil)
+	return &mockTx{m: tx.m}, nil
+}
+
+func (tx *mockTx) Commit(context.Context) error {
+	tx.m.record("COMMIT", nil)
+	return nil
+}
+
+func (tx *mockTx) Rollback(context.Context) error {
+	tx.m.record("ROLLBACK", nil)
+	return nil
+}
+
+func (tx *mockTx) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	return 0, errors.New("testhelpers: CopyFrom is not supported by mock")
+}
+
+func (tx *mockTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return tx.m.SendBatch(ctx, b)
+}
+
+// LargeObjects fails the test and panics, as pgx.LargeObjects is bound to real connection and can not be mocked.
+func (tx *mockTx) LargeObjects() pgx.LargeObjects {
+	tx.m.t.Errorf("testhelpers: LargeObjects is not supported by mock")
+	panic("testhelpers: LargeObjects is not supported by mock")
+}
+
+func (tx *mockTx) Prepare(context.Context, string, string) (*pgconn.StatementDescription, error) {
+	return nil, errors.New("testhelpers: Prepare is not supported by mock")
+}
+
+func (tx *mockTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return tx.m.Exec(ctx, sql, args...)
+}
+
+func (tx *mockTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return tx.m.Query(ctx, sql, args...)
+}
+
+func (tx *mockTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return tx.m.QueryRow(ctx, sql, args...)
+}
+
+func (tx *mockTx) Conn() *pgx.Conn {
+	return nil
+}
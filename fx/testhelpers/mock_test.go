@@ -0,0 +1,100 @@
+package testhelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/jackc/pgx/v5"
+	"sync"
+	"testing"
+)
+
+// recordingTB is testing.TB collecting errors instead of failing the test.
+type recordingTB struct {
+	testing.TB
+
+	mu     sync.Mutex
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Cleanup(func()) {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockQuerierHelpers(t *testing.T) {
+	type user struct {
+		ID   int64
+		Name string
+	}
+
+	m := NewMockQuerier(t)
+	m.Expect("SELECT id, name FROM users WHERE id = $1").
+		WithArgs(int64(1)).
+		WithColumns("id", "name").
+		Return([][]any{{int64(1), "alice"}}, nil)
+	m.Expect("UPDATE users SET name = $1").WithCommandTag("UPDATE 1")
+
+	ctx := context.Background()
+	got, err := pgxfx.QueryOne[user](ctx, m, "SELECT id, name FROM users WHERE id = $1", int64(1))
+	if err != nil {
+		t.Fatalf("QueryOne: %v", err)
+	}
+	if got != (user{ID: 1, Name: "alice"}) {
+		t.Errorf("QueryOne = %+v, want alice", got)
+	}
+
+	err = pgxfx.Transact(ctx, m, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "UPDATE users SET name = $1", "bob")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Transact: %v", err)
+	}
+
+	var sqls []string
+	for _, call := range m.Calls() {
+		sqls = append(sqls, call.SQL)
+	}
+	want := fmt.Sprint([]string{"SELECT id, name FROM users WHERE id = $1", "BEGIN", "UPDATE users SET name = $1", "COMMIT"})
+	if fmt.Sprint(sqls) != want {
+		t.Errorf("calls = %v, want %v", sqls, want)
+	}
+}
+
+func TestMockQuerierUnexpectedCall(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	m := NewMockQuerier(tb)
+	m.Expect("SELECT 1").WithArgs(1)
+
+	// unexpected calls made from other goroutines must not stop the test
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i, sql := range []string{"SELECT 2", "SELECT 1"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = m.Exec(context.Background(), sql, 2)
+		}()
+	}
+	wg.Wait()
+	_, errs[2] = m.Exec(context.Background(), "SELECT 1", 1)
+
+	for i, err := range errs[:2] {
+		if !errors.Is(err, ErrUnexpectedCall) {
+			t.Errorf("call %d: err = %v, want %v", i, err, ErrUnexpectedCall)
+		}
+	}
+	if errs[2] != nil {
+		t.Errorf("matching call: err = %v, want nil", errs[2])
+	}
+	if len(tb.errors) != 2 {
+		t.Errorf("got %d test errors, want 2: %v", len(tb.errors), tb.errors)
+	}
+}
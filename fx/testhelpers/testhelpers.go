@@ -0,0 +1,132 @@
+// Package testhelpers starts throwaway postgres in docker for integration tests of code using
+// github.com/grbisba/package/pgxpool/fx. It lives in its own module, so testcontainers-go dependency is pulled only by
+// tests that use it.
+package testhelpers
+
+import (
+	"context"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap/zaptest"
+	"testing"
+	"time"
+)
+
+const (
+	defaultImage   = "postgres:16-alpine"
+	startupTimeout = time.Minute
+)
+
+// TestOption configures NewTestPool.
+type TestOption func(o *testOptions)
+
+type testOptions struct {
+	image    string
+	initSQL  []string
+	poolOpts []pgxfx.PoolOption
+}
+
+// WithImage sets docker image of postgres container, e.g. "postgis/postgis:16-3.4".
+func WithImage(image string) TestOption {
+	return func(o *testOptions) {
+		o.image = image
+	}
+}
+
+// WithPostgresVersion sets tag of official postgres image, e.g. "15" or "16-alpine".
+func WithPostgresVersion(version string) TestOption {
+	return func(o *testOptions) {
+		o.image = "postgres:" + version
+	}
+}
+
+// WithInitSQL appends statements executed in order once pool is started.
+func WithInitSQL(sql ...string) TestOption {
+	return func(o *testOptions) {
+		o.initSQL = append(o.initSQL, sql...)
+	}
+}
+
+// WithPoolOptions appends options of pool created by NewTestPool.
+func WithPoolOptions(opts ...pgxfx.PoolOption) TestOption {
+	return func(o *testOptions) {
+		o.poolOpts = append(o.poolOpts, opts...)
+	}
+}
+
+// NewTestPool starts postgres container and returns pool connected to it by pgxfx.New, so startup ping retries are the
+// same as in services. Pool is closed and container is terminated in t.Cleanup. Any failure fails t immediately.
+func NewTestPool(t testing.TB, opts ...TestOption) *pgxpool.Pool {
+	t.Helper()
+
+	o := newTestOptions(opts)
+	dsn := startPostgres(t, o)
+
+	ctx := context.Background()
+	lc := fxtest.NewLifecycle(t)
+	pool, err := pgxfx.New(lc, dsn, pgxfx.ZapLogger(zaptest.NewLogger(t)), o.poolOpts...)
+	if err != nil {
+		t.Fatalf("testhelpers: create pool: %v", err)
+	}
+	lc.RequireStart()
+	t.Cleanup(lc.RequireStop)
+
+	for _, sql := range o.initSQL {
+		if _, err := pool.Exec(ctx, sql); err != nil {
+			t.Fatalf("testhelpers: run init sql: %v", err)
+		}
+	}
+
+	return pool
+}
+
+// StartPostgres starts postgres container terminated in t.Cleanup and returns its DSN, so tests can create pools by
+// other constructors, e.g. pgxfx.NewReadOnlyPool. Init SQL and pool options are ignored.
+func StartPostgres(t testing.TB, opts ...TestOption) string {
+	t.Helper()
+	return startPostgres(t, newTestOptions(opts))
+}
+
+func newTestOptions(opts []TestOption) testOptions {
+	o := testOptions{image: defaultImage}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func startPostgres(t testing.TB, o testOptions) string {
+	t.Helper()
+
+	ctx := context.Background()
+
+	container, err := postgres.Run(ctx, o.image,
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(startupTimeout),
+		),
+	)
+	if err != nil {
+		t.Fatalf("testhelpers: start postgres container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := container.Terminate(context.Background()); err != nil {
+			t.Errorf("testhelpers: terminate postgres container: %v", err)
+		}
+	})
+
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("testhelpers: get postgres connection string: %v", err)
+	}
+
+	return dsn
+}
@@ -0,0 +1,140 @@
+package fx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
+)
+
+// SSLMode is postgres sslmode applied by WithSSLMode.
+type SSLMode string
+
+const (
+	SSLDisable    SSLMode = "disable"
+	SSLRequire    SSLMode = "require"
+	SSLVerifyCA   SSLMode = "verify-ca"
+	SSLVerifyFull SSLMode = "verify-full"
+)
+
+var errSSLDisabledWithTLS = errors.New("postgres: ssl mode disable contradicts custom tls config")
+
+// WithSSLMode overrides sslmode of DSN. Root and client certificates configured in DSN are kept. It can not be combined
+// with WithTLSConfig when mode is SSLDisable.
+func WithSSLMode(mode SSLMode) PoolOption {
+	return func(o *poolOptions) error {
+		switch mode {
+		case SSLDisable, SSLRequire, SSLVerifyCA, SSLVerifyFull:
+		default:
+			return fmt.Errorf("postgres: unsupported ssl mode %q", mode)
+		}
+		if mode == SSLDisable && o.tlsConfig != nil {
+			return errSSLDisabledWithTLS
+		}
+
+		o.sslMode = mode
+		return nil
+	}
+}
+
+// WithTLSConfig sets cfg as TLS configuration of every connection, overriding TLS settings of DSN. It can not be
+// combined with WithSSLMode(SSLDisable).
+func WithTLSConfig(cfg *tls.Config) PoolOption {
+	return func(o *poolOptions) error {
+		if o.sslMode == SSLDisable {
+			return errSSLDisabledWithTLS
+		}
+
+		o.tlsConfig = cfg
+		return nil
+	}
+}
+
+// applyTLS applies TLS settings of options to connection config and its fallbacks.
+func (o *poolOptions) applyTLS() {
+	if o.tlsConfig == nil && o.sslMode == "" || o.unixSocket != "" {
+		return
+	}
+
+	cc := &o.config.ConnConfig.Config
+	cc.TLSConfig = o.hostTLSConfig(cc.Host, cc.TLSConfig)
+
+	fallbacks := make([]*pgconn.FallbackConfig, 0, len(cc.Fallbacks))
+	seen := map[string]bool{fmt.Sprintf("%s:%d", cc.Host, cc.Port): true}
+	for _, fb := range cc.Fallbacks {
+		// fallbacks that differ only in TLS settings become duplicates
+		key := fmt.Sprintf("%s:%d", fb.Host, fb.Port)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		fb.TLSConfig = o.hostTLSConfig(fb.Host, fb.TLSConfig)
+		fallbacks = append(fallbacks, fb)
+	}
+	cc.Fallbacks = fallbacks
+}
+
+// hostTLSConfig returns TLS configuration for host based on configuration parsed from DSN.
+func (o *poolOptions) hostTLSConfig(host string, parsed *tls.Config) *tls.Config {
+	if strings.HasPrefix(host, "/") {
+		// unix sockets do not use TLS
+		return parsed
+	}
+	if o.tlsConfig != nil {
+		return o.tlsConfig
+	}
+	if o.sslMode == SSLDisable {
+		return nil
+	}
+
+	cfg := &tls.Config{}
+	if parsed != nil {
+		cfg = parsed.Clone()
+	}
+
+	switch o.sslMode {
+	case SSLRequire:
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyPeerCertificate = nil
+	case SSLVerifyCA:
+		// chain is verified manually, because standard verification checks host name as well
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyPeerCertificate = verifyChain(cfg.RootCAs)
+	case SSLVerifyFull:
+		cfg.InsecureSkipVerify = false
+		cfg.VerifyPeerCertificate = nil
+		cfg.ServerName = host
+	}
+
+	return cfg
+}
+
+// verifyChain returns callback verifying server certificate chain against roots without checking host name. Nil roots
+// means system pool.
+func verifyChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("postgres: server did not present certificate")
+		}
+
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("postgres: parse server certificate: %w", err)
+			}
+			certs[i] = cert
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		return err
+	}
+}
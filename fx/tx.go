@@ -0,0 +1,43 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+// Transact runs fn inside transaction with default options. See TransactWithOptions.
+func Transact(ctx context.Context, q Querier, fn func(pgx.Tx) error) error {
+	return TransactWithOptions(ctx, q, pgx.TxOptions{}, fn)
+}
+
+// TransactWithOptions begins transaction with opts and calls fn. Transaction is committed when fn returns nil and rolled
+// back otherwise. Errors of fn and rollback are joined. When fn panics transaction is rolled back and panic is
+// propagated.
+func TransactWithOptions(ctx context.Context, q Querier, opts pgx.TxOptions, fn func(pgx.Tx) error) (err error) {
+	tx, err := q.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("postgres: begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("postgres: rollback transaction: %w", rbErr))
+		}
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("postgres: commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"slices"
+	"testing"
+)
+
+// fakeTx is pgx.Tx recording statements and transaction control. Methods that are not overridden panic.
+type fakeTx struct {
+	pgx.Tx
+
+	calls       []string
+	execErr     map[string]error
+	commitErr   error
+	rollbackErr error
+}
+
+func (tx *fakeTx) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	tx.calls = append(tx.calls, sql)
+	return pgconn.CommandTag{}, tx.execErr[sql]
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.calls = append(tx.calls, "COMMIT")
+	return tx.commitErr
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	tx.calls = append(tx.calls, "ROLLBACK")
+	return tx.rollbackErr
+}
+
+// fakeQuerier is Querier beginning fakeTx. Methods that are not overridden panic.
+type fakeQuerier struct {
+	Querier
+
+	tx       *fakeTx
+	beginErr error
+	opts     pgx.TxOptions
+}
+
+func (q *fakeQuerier) BeginTx(_ context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	q.opts = opts
+	if q.beginErr != nil {
+		return nil, q.beginErr
+	}
+	return q.tx, nil
+}
+
+func TestTransact(t *testing.T) {
+	errFn := errors.New("fn failed")
+	errDB := errors.New("db failed")
+
+	tests := []struct {
+		name      string
+		q         *fakeQuerier
+		fnErr     error
+		wantCalls []string
+		wantErrs  []error
+	}{
+		{
+			name:      "commit",
+			q:         &fakeQuerier{tx: &fakeTx{}},
+			wantCalls: []string{"UPDATE", "COMMIT"},
+		},
+		{
+			name:      "rollback on fn error",
+			q:         &fakeQuerier{tx: &fakeTx{}},
+			fnErr:     errFn,
+			wantCalls: []string{"UPDATE", "ROLLBACK"},
+			wantErrs:  []error{errFn},
+		},
+		{
+			name:      "rollback failure is joined",
+			q:         &fakeQuerier{tx: &fakeTx{rollbackErr: errDB}},
+			fnErr:     errFn,
+			wantCalls: []string{"UPDATE", "ROLLBACK"},
+			wantErrs:  []error{errFn, errDB},
+		},
+		{
+			name:      "commit failure",
+			q:         &fakeQuerier{tx: &fakeTx{commitErr: errDB}},
+			wantCalls: []string{"UPDATE", "COMMIT"},
+			wantErrs:  []error{errDB},
+		},
+		{
+			name:     "begin failure",
+			q:        &fakeQuerier{beginErr: errDB},
+			wantErrs: []error{errDB},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Transact(context.Background(), tt.q, func(tx pgx.Tx) error {
+				_, _ = tx.Exec(context.Background(), "UPDATE")
+				return tt.fnErr
+			})
+
+			if len(tt.wantErrs) == 0 && err != nil {
+				t.Errorf("err = %v, want nil", err)
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("err = %v, want wrapped %v", err, want)
+				}
+			}
+
+			var calls []string
+			if tt.q.tx != nil {
+				calls = tt.q.tx.calls
+			}
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestTransactPanic(t *testing.T) {
+	q := &fakeQuerier{tx: &fakeTx{}}
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("recovered %v, want boom", p)
+		}
+		if want := []string{"ROLLBACK"}; !slices.Equal(q.tx.calls, want) {
+			t.Errorf("calls = %v, want %v", q.tx.calls, want)
+		}
+	}()
+
+	_ = Transact(context.Background(), q, func(pgx.Tx) error {
+		panic("boom")
+	})
+	t.Errorf("panic was not propagated")
+}
+
+func TestTransactWithOptions(t *testing.T) {
+	q := &fakeQuerier{tx: &fakeTx{}}
+	opts := pgx.TxOptions{IsoLevel: pgx.Serializable, AccessMode: pgx.ReadOnly}
+
+	if err := TransactWithOptions(context.Background(), q, opts, func(pgx.Tx) error { return nil }); err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+	if q.opts != opts {
+		t.Errorf("transaction options = %+v, want %+v", q.opts, opts)
+	}
+}
@@ -0,0 +1,143 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"sync"
+)
+
+var errTypeNotFound = errors.New("postgres: type does not exist")
+
+// RegisterEnumType looks up OID of enum type pgTypeName and registers text codec for it and its array on conn. Already
+// registered type is skipped.
+func RegisterEnumType(ctx context.Context, conn *pgx.Conn, pgTypeName string) error {
+	if _, ok := conn.TypeMap().TypeForName(pgTypeName); ok {
+		return nil
+	}
+
+	oid, err := lookupTypeOID(ctx, conn, pgTypeName)
+	if err != nil {
+		return err
+	}
+
+	conn.TypeMap().RegisterType(&pgtype.Type{Name: pgTypeName, OID: oid, Codec: &pgtype.EnumCodec{}})
+	return RegisterArrayFor(pgTypeName)(ctx, conn)
+}
+
+// RegisterArrayFor returns AfterConnect hook registering array type of elementTypeName, which must already be known to
+// conn. Already registered array type is skipped.
+func RegisterArrayFor(elementTypeName string) func(ctx context.Context, conn *pgx.Conn) error {
+	return func(ctx context.Context, conn *pgx.Conn) error {
+		element, ok := conn.TypeMap().TypeForName(elementTypeName)
+		if !ok {
+			return fmt.Errorf("postgres: array element type %q is not registered", elementTypeName)
+		}
+
+		var (
+			arrayOID uint32
+			array    string
+		)
+		err := conn.QueryRow(ctx, "SELECT typarray, typarray::regtype::text FROM pg_type WHERE oid = $1 AND typarray <> 0",
+			element.OID).Scan(&arrayOID, &array)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("postgres: type %q has no array type", elementTypeName)
+		}
+		if err != nil {
+			return fmt.Errorf("postgres: look up array type of %q: %w", elementTypeName, err)
+		}
+
+		if _, ok := conn.TypeMap().TypeForOID(arrayOID); ok {
+			return nil
+		}
+
+		conn.TypeMap().RegisterType(&pgtype.Type{Name: array, OID: arrayOID, Codec: &pgtype.ArrayCodec{ElementType: element}})
+		return nil
+	}
+}
+
+// WithEnumTypes registers enum types with RegisterEnumType for every new connection.
+func WithEnumTypes(names ...string) PoolOption {
+	return WithAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		for _, name := range names {
+			if err := RegisterEnumType(ctx, conn, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WithHstore registers codec of hstore extension type for every new connection, so it can be scanned into and encoded
+// from map[string]*string. If the extension is not installed, warning is logged once and connections are accepted.
+func WithHstore() PoolOption {
+	return func(o *poolOptions) error {
+		var warnOnce sync.Once
+		return WithAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+			if _, ok := conn.TypeMap().TypeForName("hstore"); ok {
+				return nil
+			}
+
+			oid, err := lookupTypeOID(ctx, conn, "hstore")
+			if errors.Is(err, errTypeNotFound) {
+				warnOnce.Do(func() {
+					o.log.Warn("hstore extension is not installed, hstore codec is not registered")
+				})
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			conn.TypeMap().RegisterType(&pgtype.Type{Name: "hstore", OID: oid, Codec: pgtype.HstoreCodec{}})
+			return nil
+		})(o)
+	}
+}
+
+// EncodeHstore returns query argument encoding m as hstore.
+func EncodeHstore(m map[string]*string) interface{} {
+	return pgtype.Hstore(m)
+}
+
+// ScanHstore converts v, e.g. value returned by pgx.Rows.Values, to map. Nil v gives nil map.
+func ScanHstore(v interface{}) (map[string]*string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case pgtype.Hstore:
+		return v, nil
+	case map[string]*string:
+		return v, nil
+	case map[string]string:
+		m := make(map[string]*string, len(v))
+		for k, val := range v {
+			m[k] = &val
+		}
+		return m, nil
+	case []byte:
+		return ScanHstore(string(v))
+	case string:
+		var h pgtype.Hstore
+		if err := h.Scan(v); err != nil {
+			return nil, fmt.Errorf("postgres: scan hstore: %w", err)
+		}
+		return h, nil
+	default:
+		return nil, fmt.Errorf("postgres: scan hstore: unsupported type %T", v)
+	}
+}
+
+// lookupTypeOID returns OID of type name, which may be schema-qualified.
+func lookupTypeOID(ctx context.Context, conn *pgx.Conn, name string) (uint32, error) {
+	var oid *uint32
+	if err := conn.QueryRow(ctx, "SELECT to_regtype($1::text)::oid", name).Scan(&oid); err != nil {
+		return 0, fmt.Errorf("postgres: look up type %q: %w", name, err)
+	}
+	if oid == nil {
+		return 0, fmt.Errorf("%w: %q", errTypeNotFound, name)
+	}
+	return *oid, nil
+}
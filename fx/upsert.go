@@ -0,0 +1,125 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxQueryParams is the maximum number of parameters of single query supported by the protocol.
+const maxQueryParams = 65535
+
+// Upsert inserts values into table, which may be schema qualified, or updates updateCols of the row conflicting on
+// conflictCols. Without updateCols conflicting row is left as is. Columns are taken from values keys in sorted order,
+// so generated SQL is stable. Names that are not plain identifiers are rejected with ErrInvalidIdentifier.
+func Upsert(
+	ctx context.Context,
+	q Querier,
+	table string,
+	conflictCols, updateCols []string,
+	values map[string]any,
+) (pgconn.CommandTag, error) {
+	return UpsertMany(ctx, q, table, conflictCols, updateCols, []map[string]any{values})
+}
+
+// UpsertMany is Upsert inserting all rows in single statement. Every row must have the same set of columns, and rows
+// must not conflict with each other.
+func UpsertMany(
+	ctx context.Context,
+	q Querier,
+	table string,
+	conflictCols, updateCols []string,
+	rows []map[string]any,
+) (pgconn.CommandTag, error) {
+	sql, args, err := buildUpsert(table, conflictCols, updateCols, rows)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	tag, err := q.Exec(ctx, sql, args...)
+	if err != nil {
+		return tag, fmt.Errorf("postgres: upsert into %s: %w", table, err)
+	}
+
+	return tag, nil
+}
+
+func buildUpsert(table string, conflictCols, updateCols []string, rows []map[string]any) (string, []any, error) {
+	ident, err := parseTableName(table)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return "", nil, errors.New("postgres: upsert: no values")
+	}
+	if len(conflictCols) == 0 && len(updateCols) > 0 {
+		return "", nil, errors.New("postgres: upsert: update columns require conflict columns")
+	}
+
+	columns := slices.Sorted(maps.Keys(rows[0]))
+	if len(columns)*len(rows) > maxQueryParams {
+		return "", nil, fmt.Errorf("postgres: upsert: %d values exceed limit of %d parameters",
+			len(columns)*len(rows), maxQueryParams)
+	}
+	conflictCols = slices.Sorted(slices.Values(conflictCols))
+	updateCols = slices.Sorted(slices.Values(updateCols))
+	for _, names := range [][]string{columns, conflictCols, updateCols} {
+		if err := checkIdentifiers("column", names); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var sql strings.Builder
+	sql.WriteString("INSERT INTO ")
+	sql.WriteString(ident.Sanitize())
+	sql.WriteString(" (")
+	sql.WriteString(strings.Join(columns, ", "))
+	sql.WriteString(") VALUES ")
+
+	args := make([]any, 0, len(columns)*len(rows))
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return "", nil, fmt.Errorf("postgres: upsert: row %d has %d columns, want %d", i, len(row), len(columns))
+		}
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteByte('(')
+		for j, column := range columns {
+			v, ok := row[column]
+			if !ok {
+				return "", nil, fmt.Errorf("postgres: upsert: row %d has no column %q", i, column)
+			}
+			args = append(args, v)
+			if j > 0 {
+				sql.WriteString(", ")
+			}
+			sql.WriteString("$" + strconv.Itoa(len(args)))
+		}
+		sql.WriteByte(')')
+	}
+
+	sql.WriteString(" ON CONFLICT ")
+	if len(conflictCols) > 0 {
+		sql.WriteString("(" + strings.Join(conflictCols, ", ") + ") ")
+	}
+	if len(updateCols) == 0 {
+		sql.WriteString("DO NOTHING")
+		return sql.String(), args, nil
+	}
+
+	sql.WriteString("DO UPDATE SET ")
+	for i, column := range updateCols {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(column + " = EXCLUDED." + column)
+	}
+
+	return sql.String(), args, nil
+}
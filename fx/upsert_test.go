@@ -0,0 +1,178 @@
+package fx
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestBuildUpsert(t *testing.T) {
+	tests := []struct {
+		name         string
+		table        string
+		conflictCols []string
+		updateCols   []string
+		rows         []map[string]any
+		wantSQL      string
+		wantArgs     []any
+	}{
+		{
+			name:         "columns are sorted",
+			table:        "users",
+			conflictCols: []string{"id"},
+			updateCols:   []string{"name", "email"},
+			rows:         []map[string]any{{"name": "a", "id": 1, "email": "a@example.com"}},
+			wantSQL: "INSERT INTO \"users\" (email, id, name) VALUES ($1, $2, $3) " +
+				"ON CONFLICT (id) DO UPDATE SET email = EXCLUDED.email, name = EXCLUDED.name",
+			wantArgs: []any{"a@example.com", 1, "a"},
+		},
+		{
+			name:         "placeholders are numbered across rows",
+			table:        "public.users",
+			conflictCols: []string{"tenant", "id"},
+			updateCols:   []string{"name"},
+			rows: []map[string]any{
+				{"id": 1, "tenant": 7, "name": "a"},
+				{"id": 2, "tenant": 7, "name": "b"},
+			},
+			wantSQL: "INSERT INTO \"public\".\"users\" (id, name, tenant) VALUES ($1, $2, $3), ($4, $5, $6) " +
+				"ON CONFLICT (id, tenant) DO UPDATE SET name = EXCLUDED.name",
+			wantArgs: []any{1, "a", 7, 2, "b", 7},
+		},
+		{
+			name:         "do nothing without update columns",
+			table:        "users",
+			conflictCols: []string{"id"},
+			rows:         []map[string]any{{"id": 1}},
+			wantSQL:      "INSERT INTO \"users\" (id) VALUES ($1) ON CONFLICT (id) DO NOTHING",
+			wantArgs:     []any{1},
+		},
+		{
+			name:     "do nothing on any conflict",
+			table:    "users",
+			rows:     []map[string]any{{"id": 1}},
+			wantSQL:  "INSERT INTO \"users\" (id) VALUES ($1) ON CONFLICT DO NOTHING",
+			wantArgs: []any{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := buildUpsert(tt.table, tt.conflictCols, tt.updateCols, tt.rows)
+			if err != nil {
+				t.Fatalf("err = %v, want nil", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %s\nwant %s", sql, tt.wantSQL)
+			}
+			if !slices.Equal(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildUpsertErrors(t *testing.T) {
+	tooMany := make([]map[string]any, maxQueryParams/2+1)
+	for i := range tooMany {
+		tooMany[i] = map[string]any{"a": i, "b": i}
+	}
+
+	tests := []struct {
+		name         string
+		table        string
+		conflictCols []string
+		updateCols   []string
+		rows         []map[string]any
+		wantErr      error
+	}{
+		{name: "no rows", table: "users"},
+		{name: "empty row", table: "users", rows: []map[string]any{{}}},
+		{
+			name:       "update columns without conflict columns",
+			table:      "users",
+			updateCols: []string{"name"},
+			rows:       []map[string]any{{"name": "a"}},
+		},
+		{
+			name:  "row with fewer columns",
+			table: "users",
+			rows:  []map[string]any{{"id": 1, "name": "a"}, {"id": 2}},
+		},
+		{
+			name:  "row with other columns",
+			table: "users",
+			rows:  []map[string]any{{"id": 1, "name": "a"}, {"id": 2, "email": "b"}},
+		},
+		{name: "parameter limit", table: "users", rows: tooMany},
+		{
+			name:    "invalid table name",
+			table:   "users; DROP TABLE users",
+			rows:    []map[string]any{{"id": 1}},
+			wantErr: ErrInvalidIdentifier,
+		},
+		{
+			name:    "too many table name parts",
+			table:   "db.public.users",
+			rows:    []map[string]any{{"id": 1}},
+			wantErr: ErrInvalidIdentifier,
+		},
+		{
+			name:    "invalid column name",
+			table:   "users",
+			rows:    []map[string]any{{"id) VALUES (1": 1}},
+			wantErr: ErrInvalidIdentifier,
+		},
+		{
+			name:         "invalid conflict column name",
+			table:        "users",
+			conflictCols: []string{"id\""},
+			rows:         []map[string]any{{"id": 1}},
+			wantErr:      ErrInvalidIdentifier,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := buildUpsert(tt.table, tt.conflictCols, tt.updateCols, tt.rows)
+			if err == nil {
+				t.Fatal("err = nil, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTableName(t *testing.T) {
+	tests := []struct {
+		table string
+		want  []string
+	}{
+		{table: "users", want: []string{"users"}},
+		{table: "public.users", want: []string{"public", "users"}},
+		{table: "_audit.log_2024", want: []string{"_audit", "log_2024"}},
+		{table: ""},
+		{table: "public."},
+		{table: ".users"},
+		{table: "a.b.c"},
+		{table: "1users"},
+		{table: `"users"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			got, err := parseTableName(tt.table)
+			if tt.want == nil {
+				if !errors.Is(err, ErrInvalidIdentifier) {
+					t.Errorf("err = %v, want %v", err, ErrInvalidIdentifier)
+				}
+				return
+			}
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Errorf("parseTableName = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Package viper reads pool configuration of github.com/grbisba/package/pgxpool/fx from spf13/viper. It lives in its
+// own module, so viper dependency is pulled only by services that use it.
+package viper
+
+import (
+	"fmt"
+	pgxfx "github.com/grbisba/package/pgxpool/fx"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"time"
+)
+
+// PoolConfigFromViper reads PoolConfig from keys under keyPrefix. Missing keys keep pgxfx.DefaultPoolConfig values:
+//
+//	<prefix>.dsn                 string, required
+//	<prefix>.max_conns           int32, default 10
+//	<prefix>.min_conns           int32, default 0
+//	<prefix>.max_conn_lifetime   duration, default 1h
+//	<prefix>.max_conn_idle_time  duration, default 30m
+//	<prefix>.health_check_period duration, default 1m
+//	<prefix>.connect_timeout     duration, default 5s
+//
+// Durations are accepted in time.ParseDuration format or as integer nanoseconds. Empty keyPrefix reads top level keys.
+// All parse and validation errors are returned joined together.
+func PoolConfigFromViper(v *viper.Viper, keyPrefix string) (pgxfx.PoolConfig, error) {
+	cfg := pgxfx.DefaultPoolConfig()
+	key := func(name string) string {
+		if keyPrefix == "" {
+			return name
+		}
+		return keyPrefix + "." + name
+	}
+
+	var errs []error
+
+	cfg.DSN = v.GetString(key("dsn"))
+	for _, f := range []struct {
+		name string
+		dst  *int32
+	}{
+		{"max_conns", &cfg.MaxConns},
+		{"min_conns", &cfg.MinConns},
+	} {
+		if !v.IsSet(key(f.name)) {
+			continue
+		}
+		n, err := cast.ToInt32E(v.Get(key(f.name)))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid integer: %w", key(f.name), err))
+			continue
+		}
+		*f.dst = n
+	}
+	for _, f := range []struct {
+		name string
+		dst  *time.Duration
+	}{
+		{"max_conn_lifetime", &cfg.MaxConnLifetime},
+		{"max_conn_idle_time", &cfg.MaxConnIdleTime},
+		{"health_check_period", &cfg.HealthCheckPeriod},
+		{"connect_timeout", &cfg.ConnectTimeout},
+	} {
+		if !v.IsSet(key(f.name)) {
+			continue
+		}
+		d, err := cast.ToDurationE(v.Get(key(f.name)))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid duration: %w", key(f.name), err))
+			continue
+		}
+		*f.dst = d
+	}
+
+	if err := cfg.ValidateWith(errs...); err != nil {
+		return pgxfx.PoolConfig{}, fmt.Errorf("postgres: read pool config from viper: %w", err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,53 @@
+package fx
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync"
+)
+
+// WithWarmup makes OnStart open up to n connections, bounded by pool MaxConns, after successful ping, so first requests
+// do not pay connection setup latency. Failed acquires are logged and never fail OnStart. Warmup stops early when
+// OnStart context is done.
+func WithWarmup(n int32) PoolOption {
+	return func(o *poolOptions) error {
+		o.warmup = n
+		return nil
+	}
+}
+
+// warm acquires warmup connections concurrently and releases them after all acquires finish, so each of them is a
+// separate connection.
+func (o *poolOptions) warm(ctx context.Context, pool *pgxpool.Pool) {
+	n := min(o.warmup, pool.Config().MaxConns)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		conns = make([]*pgxpool.Conn, 0, n)
+	)
+
+	for i := int32(0); i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			conn, err := pool.Acquire(ctx)
+			if err != nil {
+				o.log.Warn("failed to acquire connection during warmup", "error", err)
+				return
+			}
+
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for _, conn := range conns {
+		conn.Release()
+	}
+
+	o.log.Info("warmed up postgres pool", "established", len(conns), "attempted", n)
+}
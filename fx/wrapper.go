@@ -0,0 +1,139 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"net"
+	"strconv"
+)
+
+var _ Querier = (*PoolWrapper)(nil)
+
+// PoolError is error returned by PoolWrapper. It tells which pool the error came from.
+type PoolError struct {
+	// PoolName is the name passed to WrapPool.
+	PoolName string
+	// Host is host and port of the pool config.
+	Host string
+	// Err is the original error.
+	Err error
+}
+
+func (e *PoolError) Error() string {
+	return fmt.Sprintf("postgres: pool %s (%s): %v", e.PoolName, e.Host, e.Err)
+}
+
+func (e *PoolError) Unwrap() error {
+	return e.Err
+}
+
+// PoolWrapper is Querier wrapping every error of pool, including errors of rows and batch results, in *PoolError.
+type PoolWrapper struct {
+	pool *pgxpool.Pool
+	name string
+	host string
+}
+
+// WrapPool returns PoolWrapper of pool tagging errors with name.
+func WrapPool(pool *pgxpool.Pool, name string) *PoolWrapper {
+	cfg := pool.Config().ConnConfig
+	return &PoolWrapper{
+		pool: pool,
+		name: name,
+		host: net.JoinHostPort(cfg.Host, strconv.Itoa(int(cfg.Port))),
+	}
+}
+
+// Pool returns the wrapped pool.
+func (w *PoolWrapper) Pool() *pgxpool.Pool {
+	return w.pool
+}
+
+// Exec acts like pgxpool.Pool.Exec.
+func (w *PoolWrapper) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := w.pool.Exec(ctx, sql, args...)
+	return tag, w.wrap(err)
+}
+
+// Query acts like pgxpool.Pool.Query.
+func (w *PoolWrapper) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := w.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, w.wrap(err)
+	}
+	return wrappedRows{Rows: rows, w: w}, nil
+}
+
+// QueryRow acts like pgxpool.Pool.QueryRow.
+func (w *PoolWrapper) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return wrappedRow{row: w.pool.QueryRow(ctx, sql, args...), w: w}
+}
+
+// SendBatch acts like pgxpool.Pool.SendBatch.
+func (w *PoolWrapper) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return wrappedBatchResults{results: w.pool.SendBatch(ctx, b), w: w}
+}
+
+// BeginTx acts like pgxpool.Pool.BeginTx. Errors of returned transaction are not wrapped.
+func (w *PoolWrapper) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	tx, err := w.pool.BeginTx(ctx, txOptions)
+	return tx, w.wrap(err)
+}
+
+func (w *PoolWrapper) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PoolError{PoolName: w.name, Host: w.host, Err: err}
+}
+
+type wrappedRows struct {
+	pgx.Rows
+	w *PoolWrapper
+}
+
+func (r wrappedRows) Err() error {
+	return r.w.wrap(r.Rows.Err())
+}
+
+func (r wrappedRows) Scan(dest ...any) error {
+	return r.w.wrap(r.Rows.Scan(dest...))
+}
+
+type wrappedRow struct {
+	row pgx.Row
+	w   *PoolWrapper
+}
+
+func (r wrappedRow) Scan(dest ...any) error {
+	return r.w.wrap(r.row.Scan(dest...))
+}
+
+type wrappedBatchResults struct {
+	results pgx.BatchResults
+	w       *PoolWrapper
+}
+
+func (r wrappedBatchResults) Exec() (pgconn.CommandTag, error) {
+	tag, err := r.results.Exec()
+	return tag, r.w.wrap(err)
+}
+
+func (r wrappedBatchResults) Query() (pgx.Rows, error) {
+	rows, err := r.results.Query()
+	if err != nil {
+		return rows, r.w.wrap(err)
+	}
+	return wrappedRows{Rows: rows, w: r.w}, nil
+}
+
+func (r wrappedBatchResults) QueryRow() pgx.Row {
+	return wrappedRow{row: r.results.QueryRow(), w: r.w}
+}
+
+func (r wrappedBatchResults) Close() error {
+	return r.w.wrap(r.results.Close())
+}